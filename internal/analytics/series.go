@@ -0,0 +1,243 @@
+// Package analytics computes training statistics inside MongoDB with
+// aggregation pipelines, so that only the aggregated rows leave the database.
+// The pipelines themselves live in the database package.
+package analytics
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidGranularity = errors.New("granularity must be day, week, month or year")
+	ErrInvalidMuscleGroup = errors.New("unknown muscle group")
+	ErrTooManyBuckets     = errors.New("range has too many buckets for the granularity")
+)
+
+// Bucket sizes, named after the units of $dateTrunc. Weeks start on Monday.
+const (
+	Day   = "day"
+	Week  = "week"
+	Month = "month"
+	Year  = "year"
+)
+
+// Granularities lists the valid bucket sizes.
+var Granularities = []string{Day, Week, Month, Year}
+
+// maxBuckets bounds a series so that a year of days is the longest.
+const maxBuckets = 400
+
+// Filter selects the workouts and exercises that go into a series. Buckets
+// are cut at midnight in Location. Exercises narrows the series to the given
+// exercises and MuscleGroup to those that train it as a primary muscle; both
+// together keep the exercises that match both.
+type Filter struct {
+	UserID      primitive.ObjectID
+	From        time.Time
+	To          time.Time
+	Granularity string
+	Location    *time.Location
+	Exercises   []primitive.ObjectID
+	RoutineID   primitive.ObjectID
+	MuscleGroup string
+}
+
+// Bucket holds the totals of one period. Tonnage is the external load lifted,
+// reps times the logged weight; Volume also adds the lifter's bodyweight on
+// bodyweight exercises, as the muscle volume analytics do. Workouts counts
+// the workouts with at least one matching exercise.
+type Bucket struct {
+	Start    time.Time `json:"start"`
+	Workouts int       `json:"workouts"`
+	Sets     int       `json:"sets"`
+	Reps     int       `json:"reps"`
+	Volume   float64   `json:"volume"`
+	Tonnage  float64   `json:"tonnage"`
+}
+
+// Series is a run of consecutive buckets, empty periods included, with their
+// sum.
+type Series struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Granularity string    `json:"granularity"`
+	TimeZone    string    `json:"time_zone"`
+	Buckets     []Bucket  `json:"buckets"`
+	Totals      Bucket    `json:"totals"`
+	Unit        string    `json:"unit,omitempty"`
+}
+
+// SeriesFor aggregates the user's workouts in [filter.From, filter.To) into
+// buckets of the filter's granularity. Weights are in kilograms.
+func SeriesFor(filter Filter) (*Series, error) {
+	if !slices.Contains(Granularities, filter.Granularity) {
+		return nil, ErrInvalidGranularity
+	}
+	if filter.MuscleGroup != "" && !slices.Contains(models.MuscleGroups, filter.MuscleGroup) {
+		return nil, ErrInvalidMuscleGroup
+	}
+	if filter.Location == nil {
+		filter.Location = time.UTC
+	}
+
+	starts := bucketStarts(filter.From, filter.To, filter.Granularity, filter.Location)
+	if len(starts) > maxBuckets {
+		return nil, ErrTooManyBuckets
+	}
+
+	series := &Series{
+		From:        filter.From,
+		To:          filter.To,
+		Granularity: filter.Granularity,
+		TimeZone:    filter.Location.String(),
+		Buckets:     make([]Bucket, 0, len(starts)),
+	}
+
+	exercises, filtered, err := filterExercises(filter)
+	if err != nil {
+		return nil, err
+	}
+	rows := map[int64]Bucket{}
+	if !filtered || len(exercises) > 0 {
+		if rows, err = aggregateBuckets(filter, exercises); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, start := range starts {
+		bucket := rows[start.UnixMilli()]
+		bucket.Start = start
+		series.Buckets = append(series.Buckets, bucket)
+
+		series.Totals.Workouts += bucket.Workouts
+		series.Totals.Sets += bucket.Sets
+		series.Totals.Reps += bucket.Reps
+		series.Totals.Volume += bucket.Volume
+		series.Totals.Tonnage += bucket.Tonnage
+	}
+	series.Totals.Start = series.From
+	return series, nil
+}
+
+// SeriesForDisplay converts the volumes of a series from kilograms.
+func SeriesForDisplay(series *Series, unit string) {
+	for i := range series.Buckets {
+		b := &series.Buckets[i]
+		b.Volume = service.DisplayAmount(b.Volume, unit)
+		b.Tonnage = service.DisplayAmount(b.Tonnage, unit)
+	}
+	series.Totals.Volume = service.DisplayAmount(series.Totals.Volume, unit)
+	series.Totals.Tonnage = service.DisplayAmount(series.Totals.Tonnage, unit)
+	series.Unit = unit
+}
+
+// filterExercises resolves the exercise and muscle group filters to the
+// exercise IDs to keep. filtered is false when every exercise counts.
+func filterExercises(filter Filter) (ids []primitive.ObjectID, filtered bool, err error) {
+	if filter.MuscleGroup == "" {
+		return filter.Exercises, len(filter.Exercises) > 0, nil
+	}
+	ids, err = database.GetMuscleExerciseIDs(filter.UserID, filter.MuscleGroup, filter.Exercises)
+	return ids, true, err
+}
+
+// aggregateBuckets totals the filter's workouts, leaving out the warm-up and
+// cool-down, and returns the non-empty buckets keyed by the Unix
+// milliseconds of their start. An empty exercises slice keeps every
+// exercise.
+func aggregateBuckets(filter Filter, exercises []primitive.ObjectID) (map[int64]Bucket, error) {
+	bodyweightIDs, err := database.GetBodyweightExerciseIDs(filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := database.AggregateWorkoutBuckets(database.BucketQuery{
+		UserID:              filter.UserID,
+		From:                filter.From,
+		To:                  filter.To,
+		Unit:                filter.Granularity,
+		Location:            filter.Location,
+		RoutineID:           filter.RoutineID,
+		Exercises:           exercises,
+		Excluded:            service.StaticExerciseIDs(),
+		BodyweightExercises: bodyweightIDs,
+		BodyweightEquipment: service.BodyweightEquipmentNames(),
+		FallbackBodyweight:  userBodyweight(filter.UserID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[int64]Bucket, len(totals))
+	for _, t := range totals {
+		rows[int64(t.Start)] = Bucket{
+			Workouts: t.Workouts,
+			Sets:     t.Sets,
+			Reps:     t.Reps,
+			Volume:   t.Volume,
+			Tonnage:  t.Tonnage,
+		}
+	}
+	return rows, nil
+}
+
+// bucketStarts lists the start of every bucket overlapping [from, to),
+// truncating the same way $dateTrunc does in loc.
+func bucketStarts(from, to time.Time, granularity string, loc *time.Location) []time.Time {
+	var starts []time.Time
+	for start := truncate(from, granularity, loc); start.Before(to); start = next(start, granularity) {
+		starts = append(starts, start)
+		if len(starts) > maxBuckets {
+			break
+		}
+	}
+	return starts
+}
+
+func truncate(t time.Time, granularity string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+	switch granularity {
+	case Week:
+		// Monday is the first day of the week
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case Year:
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+}
+
+func next(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	case Year:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// userBodyweight is the profile weight used for workouts logged before any
+// bodyweight entry.
+func userBodyweight(userID primitive.ObjectID) float64 {
+	user, err := database.GetUserByID(userID)
+	if err != nil {
+		return 0
+	}
+	return user.Weight
+}
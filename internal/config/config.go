@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -14,15 +16,85 @@ type Config struct {
 	Port              string
 	StaticExercises   StaticExercises
 	ExercisesJSONPath string
+
+	// SupportedLocales lists the languages catalog content may be served in;
+	// DefaultLocale is always one of them.
+	SupportedLocales []string
+
+	Sessions  SessionConfig
+	Analytics AnalyticsConfig
+	Media     MediaConfig
+	Units     UnitsConfig
 }
 
 var AppConfig Config
 
+// DefaultLocale is the language of the untranslated catalog fields.
+const DefaultLocale = "en"
+
 type StaticExercises struct {
 	WarmupID   string
 	CooldownID string
 }
 
+// Session expiry actions: "finish" saves an expired session that has logged
+// sets as a partial workout, "archive" always moves it to the session archive.
+const (
+	SessionExpiryFinish  = "finish"
+	SessionExpiryArchive = "archive"
+)
+
+// AnalyticsConfig holds the weekly hard-set target, with overrides for
+// individual muscle groups, the fraction of a set credited to secondary muscles, the training load
+// thresholds that raise warnings, and how plateaus are detected: the window
+// compared with the one before it, the sessions it needs, and the relative
+// changes below which an exercise has stalled or regressed.
+type AnalyticsConfig struct {
+	WeeklySetsMin       float64
+	WeeklySetsMax       float64
+	MuscleSets          map[string]SetRange
+	SecondaryCredit     float64
+	ACWRHigh            float64
+	ACWRLow             float64
+	MonotonyHigh        float64
+	PlateauWeeks        int
+	PlateauMinSessions  int
+	PlateauThreshold    float64
+	RegressionThreshold float64
+}
+
+// SetRange is a weekly hard-set range.
+type SetRange struct {
+	Min float64
+	Max float64
+}
+
+// MediaStoreLocal keeps uploaded media on the local filesystem.
+const MediaStoreLocal = "local"
+
+// MediaConfig selects the blob store backend for exercise media and caps
+// upload sizes.
+type MediaConfig struct {
+	Store         string
+	LocalRoot     string
+	MaxUploadMB   int
+	AllowedImages []string
+	AllowedVideos []string
+}
+
+// UnitsConfig holds the plate increments lifting weights are rounded to when
+// shown in each unit.
+type UnitsConfig struct {
+	IncrementKg float64
+	IncrementLb float64
+}
+
+type SessionConfig struct {
+	ExpiryHours  int
+	ExpiryAction string
+	SweepMinutes int
+}
+
 func LoadConfig() {
 	log.Println("Loading .env file...")
 	err := godotenv.Load()
@@ -36,11 +108,52 @@ func LoadConfig() {
 
 	exercisesJSON := getEnvWithDefault("EXERCISES_JSON_PATH", "exercises.json")
 
+	supportedLocales := []string{DefaultLocale}
+	for _, locale := range strings.Split(getEnvWithDefault("SUPPORTED_LOCALES", "en,de,es"), ",") {
+		locale = strings.ToLower(strings.TrimSpace(locale))
+		if locale != "" && locale != DefaultLocale {
+			supportedLocales = append(supportedLocales, locale)
+		}
+	}
+
 	// Do not use hardcoded defaults; rely on DB resolution at startup.
 	// If provided explicitly via env, they will be used initially and overridden by DB lookups.
 	warmupID := getEnvWithDefault("WARMUP_ID", "")
 	cooldownID := getEnvWithDefault("COOLDOWN_ID", "")
 
+	sessionExpiryHours := getIntEnvWithDefault("SESSION_EXPIRY_HOURS", 48)
+	sessionExpiryAction := getEnvWithDefault("SESSION_EXPIRY_ACTION", SessionExpiryFinish)
+	sessionSweepMinutes := getIntEnvWithDefault("SESSION_SWEEP_MINUTES", 10)
+
+	weeklySetsMin := getFloatEnvWithDefault("WEEKLY_SETS_MIN", 10)
+	weeklySetsMax := getFloatEnvWithDefault("WEEKLY_SETS_MAX", 20)
+	muscleSets := getSetRangesEnv("WEEKLY_SETS_BY_MUSCLE")
+	secondaryCredit := getFloatEnvWithDefault("SECONDARY_MUSCLE_CREDIT", 0.5)
+	acwrHigh := getFloatEnvWithDefault("ACWR_HIGH", 1.5)
+	acwrLow := getFloatEnvWithDefault("ACWR_LOW", 0.8)
+	monotonyHigh := getFloatEnvWithDefault("MONOTONY_HIGH", 2.0)
+	plateauWeeks := getIntEnvWithDefault("PLATEAU_WINDOW_WEEKS", 6)
+	plateauMinSessions := getIntEnvWithDefault("PLATEAU_MIN_SESSIONS", 3)
+	plateauThreshold := getFloatEnvWithDefault("PLATEAU_THRESHOLD", 0.01)
+	regressionThreshold := getFloatEnvWithDefault("REGRESSION_THRESHOLD", 0.025)
+
+	incrementKg := getFloatEnvWithDefault("WEIGHT_INCREMENT_KG", 2.5)
+	incrementLb := getFloatEnvWithDefault("WEIGHT_INCREMENT_LB", 5)
+
+	mediaStore := getEnvWithDefault("MEDIA_STORE", MediaStoreLocal)
+	mediaRoot := getEnvWithDefault("MEDIA_LOCAL_ROOT", "media")
+	mediaMaxUploadMB := getIntEnvWithDefault("MEDIA_MAX_UPLOAD_MB", 50)
+
+	if sessionExpiryAction != SessionExpiryFinish && sessionExpiryAction != SessionExpiryArchive {
+		log.Printf("Unknown SESSION_EXPIRY_ACTION %q — using %q", sessionExpiryAction, SessionExpiryFinish)
+		sessionExpiryAction = SessionExpiryFinish
+	}
+
+	if weeklySetsMin > weeklySetsMax {
+		log.Printf("WEEKLY_SETS_MIN (%g) exceeds WEEKLY_SETS_MAX (%g) — using 10-20", weeklySetsMin, weeklySetsMax)
+		weeklySetsMin, weeklySetsMax = 10, 20
+	}
+
 	if uri == "" || db == "" {
 		log.Fatal("Missing environment variables: MONGODB_URI and/or MONGODB_DBNAME")
 	}
@@ -51,11 +164,46 @@ func LoadConfig() {
 		Port:     port,
 
 		ExercisesJSONPath: exercisesJSON,
+		SupportedLocales:  supportedLocales,
 
 		StaticExercises: StaticExercises{
 			WarmupID:   warmupID,
 			CooldownID: cooldownID,
 		},
+
+		Sessions: SessionConfig{
+			ExpiryHours:  sessionExpiryHours,
+			ExpiryAction: sessionExpiryAction,
+			SweepMinutes: sessionSweepMinutes,
+		},
+
+		Analytics: AnalyticsConfig{
+			WeeklySetsMin:   weeklySetsMin,
+			WeeklySetsMax:   weeklySetsMax,
+			MuscleSets:      muscleSets,
+			SecondaryCredit: secondaryCredit,
+			ACWRHigh:        acwrHigh,
+			ACWRLow:         acwrLow,
+			MonotonyHigh:    monotonyHigh,
+
+			PlateauWeeks:        plateauWeeks,
+			PlateauMinSessions:  plateauMinSessions,
+			PlateauThreshold:    plateauThreshold,
+			RegressionThreshold: regressionThreshold,
+		},
+
+		Units: UnitsConfig{
+			IncrementKg: incrementKg,
+			IncrementLb: incrementLb,
+		},
+
+		Media: MediaConfig{
+			Store:         mediaStore,
+			LocalRoot:     mediaRoot,
+			MaxUploadMB:   mediaMaxUploadMB,
+			AllowedImages: []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+			AllowedVideos: []string{"video/mp4", "video/webm"},
+		},
 	}
 }
 
@@ -67,3 +215,70 @@ func getEnvWithDefault(key, defaultValue string) (value string) {
 	}
 	return
 }
+
+func getIntEnvWithDefault(key string, defaultValue int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		log.Println("Using default value for ", key)
+		return defaultValue
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		log.Printf("Invalid value for %s (%q) — using default %d", key, raw, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+func getFloatEnvWithDefault(key string, defaultValue float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		log.Println("Using default value for ", key)
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		log.Printf("Invalid value for %s (%q) — using default %g", key, raw, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// getSetRangesEnv parses per-muscle set ranges written as
+// "chest=12-20,biceps=8-14". Malformed entries are logged and skipped.
+func getSetRangesEnv(key string) map[string]SetRange {
+	ranges := map[string]SetRange{}
+	raw := os.Getenv(key)
+	if raw == "" {
+		return ranges
+	}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		muscle, bounds, _ := strings.Cut(entry, "=")
+		low, high, _ := strings.Cut(bounds, "-")
+		r, err := parseSetRange(low, high)
+		muscle = strings.ToLower(strings.TrimSpace(muscle))
+		if err != nil || muscle == "" {
+			log.Printf("Invalid entry in %s (%q) — skipping it", key, entry)
+			continue
+		}
+		ranges[muscle] = r
+	}
+	return ranges
+}
+
+func parseSetRange(low, high string) (r SetRange, err error) {
+	if r.Min, err = strconv.ParseFloat(strings.TrimSpace(low), 64); err != nil {
+		return
+	}
+	if r.Max, err = strconv.ParseFloat(strings.TrimSpace(high), 64); err != nil {
+		return
+	}
+	if r.Min < 0 || r.Min > r.Max {
+		err = strconv.ErrRange
+	}
+	return
+}
@@ -0,0 +1,366 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BucketQuery selects the workouts to total and how to bucket them. Unit is
+// a $dateTrunc unit, with weeks starting on Monday. An empty Exercises keeps
+// every exercise; Excluded exercises, such as the warm-up, never count.
+// Sets of BodyweightExercises done with BodyweightEquipment also carry the
+// lifter's bodyweight, falling back to FallbackBodyweight when none was
+// logged.
+type BucketQuery struct {
+	UserID              primitive.ObjectID
+	From                time.Time
+	To                  time.Time
+	Unit                string
+	Location            *time.Location
+	RoutineID           primitive.ObjectID
+	Exercises           []primitive.ObjectID
+	Excluded            []primitive.ObjectID
+	BodyweightExercises []primitive.ObjectID
+	BodyweightEquipment []string
+	FallbackBodyweight  float64
+}
+
+// BucketTotals are the totals of the working sets in one bucket.
+type BucketTotals struct {
+	Start    primitive.DateTime `bson:"_id"`
+	Workouts int                `bson:"workouts"`
+	Sets     int                `bson:"sets"`
+	Reps     int                `bson:"reps"`
+	Volume   float64            `bson:"volume"`
+	Tonnage  float64            `bson:"tonnage"`
+}
+
+// AggregateWorkoutBuckets totals the user's workouts into buckets, oldest
+// first. Empty buckets are left out.
+func AggregateWorkoutBuckets(query BucketQuery) (buckets []BucketTotals, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	collection := GetCollection("workouts")
+
+	cursor, err := collection.Aggregate(ctx, bucketPipeline(query))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var bucket BucketTotals
+		if err := cursor.Decode(&bucket); err != nil {
+			log.Printf("Error decoding analytics bucket: %v", err)
+			continue
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	err = cursor.Err()
+	return
+}
+
+// bucketPipeline builds the aggregation over the workouts collection. Each
+// workout is matched on user, date and routine, given the bodyweight it was
+// done at (recorded with the workout, else the last logged one, else the
+// fallback), unwound into its exercises, reduced to the totals of its working
+// sets and grouped by the start of its bucket in the query's time zone, by
+// the local date it started on when known. The stored dates are UTC, so
+// workouts are matched a day either side of the range and then kept by the
+// day they count for.
+func bucketPipeline(query BucketQuery) mongo.Pipeline {
+	match := bson.M{
+		"userID":      query.UserID,
+		"workoutDate": bson.M{"$gte": query.From.AddDate(0, 0, -1), "$lt": query.To.AddDate(0, 0, 1)},
+	}
+	if !query.RoutineID.IsZero() {
+		match["routineID"] = query.RoutineID
+	}
+	if len(query.Exercises) > 0 {
+		match["exercises.exerciseID"] = bson.M{"$in": query.Exercises}
+	}
+
+	// A workout counts for the date it started on the lifter's clock, taken
+	// as that date in the query's time zone
+	workoutDay := bson.M{"$dateFromString": bson.M{
+		"dateString": bson.M{"$substrBytes": bson.A{bson.M{"$ifNull": bson.A{"$localStart", ""}}, 0, 10}},
+		"format":     "%Y-%m-%d",
+		"timezone":   query.Location.String(),
+		"onError":    "$workoutDate",
+	}}
+
+	trunc := bson.M{
+		"date":     "$workoutDay",
+		"unit":     query.Unit,
+		"timezone": query.Location.String(),
+	}
+	if query.Unit == "week" {
+		trunc["startOfWeek"] = "monday"
+	}
+
+	isBodyweight := bson.M{"$and": bson.A{
+		bson.M{"$in": bson.A{"$exercises.exerciseID", query.BodyweightExercises}},
+		bson.M{"$in": bson.A{
+			bson.M{"$toLower": bson.M{"$trim": bson.M{"input": bson.M{"$ifNull": bson.A{"$exercises.equipment", ""}}}}},
+			query.BodyweightEquipment,
+		}},
+	}}
+
+	exerciseMatch := bson.M{}
+	if len(query.Exercises) > 0 {
+		exerciseMatch["$in"] = query.Exercises
+	}
+	if len(query.Excluded) > 0 {
+		exerciseMatch["$nin"] = query.Excluded
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$addFields", Value: bson.M{"workoutDay": workoutDay}}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": bson.A{
+			bson.M{"$gte": bson.A{"$workoutDay", query.From}},
+			bson.M{"$lt": bson.A{"$workoutDay", query.To}},
+		}}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "measurements",
+			"let":  bson.M{"workoutDate": "$workoutDate"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"userID":     query.UserID,
+					"bodyweight": bson.M{"$gt": 0},
+					"$expr":      bson.M{"$lte": bson.A{"$date", "$$workoutDate"}},
+				}},
+				bson.M{"$sort": bson.M{"date": -1}},
+				bson.M{"$limit": 1},
+				bson.M{"$project": bson.M{"_id": 0, "bodyweight": 1}},
+			},
+			"as": "loggedBodyweight",
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"bodyweight": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$metadata.bodyweight", 0}}, 0}},
+				"$metadata.bodyweight",
+				bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$loggedBodyweight.bodyweight", 0}}, query.FallbackBodyweight}},
+			}},
+		}}},
+		{{Key: "$unwind", Value: "$exercises"}},
+	}
+	if len(exerciseMatch) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"exercises.exerciseID": exerciseMatch}}})
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$project", Value: bson.M{
+			"bucket": bson.M{"$dateTrunc": trunc},
+			"workingSets": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$exercises.sets", bson.A{}}},
+				"as":    "s",
+				"cond":  bson.M{"$gt": bson.A{"$$s.reps", 0}},
+			}},
+			"addedWeight": bson.M{"$cond": bson.A{isBodyweight, "$bodyweight", 0}},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":      "$bucket",
+			"workouts": bson.M{"$addToSet": "$_id"},
+			"sets":     bson.M{"$sum": bson.M{"$size": "$workingSets"}},
+			"reps":     bson.M{"$sum": bson.M{"$sum": "$workingSets.reps"}},
+			"tonnage": bson.M{"$sum": bson.M{"$sum": bson.M{"$map": bson.M{
+				"input": "$workingSets",
+				"as":    "s",
+				"in":    bson.M{"$multiply": bson.A{"$$s.reps", "$$s.weight"}},
+			}}}},
+			"volume": bson.M{"$sum": bson.M{"$sum": bson.M{"$map": bson.M{
+				"input": "$workingSets",
+				"as":    "s",
+				"in":    bson.M{"$multiply": bson.A{"$$s.reps", bson.M{"$add": bson.A{"$$s.weight", "$addedWeight"}}}},
+			}}}},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{"workouts": bson.M{"$size": "$workouts"}}}},
+		bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	)
+	return pipeline
+}
+
+// GetMuscleExerciseIDs returns the exercises, global or the user's own, that
+// train muscle as a primary muscle, limited to within when it is set.
+func GetMuscleExerciseIDs(userID primitive.ObjectID, muscle string, within []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	query := bson.M{
+		"primaryMuscles": muscle,
+		"$or": []bson.M{
+			{"ownerID": bson.M{"$exists": false}},
+			{"ownerID": userID},
+		},
+	}
+	if len(within) > 0 {
+		query["_id"] = bson.M{"$in": within}
+	}
+	return findExerciseIDs(query)
+}
+
+// GetBodyweightExerciseIDs returns the exercises, global or the user's own,
+// that list bodyweight as equipment.
+func GetBodyweightExerciseIDs(userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return findExerciseIDs(bson.M{
+		"equipment": primitive.Regex{Pattern: `^\s*bodyweights?\s*$`, Options: "i"},
+		"$or": []bson.M{
+			{"ownerID": bson.M{"$exists": false}},
+			{"ownerID": userID},
+		},
+	})
+}
+
+func findExerciseIDs(query bson.M) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exercises")
+
+	cursor, err := collection.Find(ctx, query, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	ids := []primitive.ObjectID{}
+	for cursor.Next(ctx) {
+		var exercise struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&exercise); err != nil {
+			continue
+		}
+		ids = append(ids, exercise.ID)
+	}
+	return ids, cursor.Err()
+}
+
+// ExerciseTotals are an exercise's totals in one workout, with every entry of
+// the exercise and variation combined.
+type ExerciseTotals struct {
+	ExerciseID primitive.ObjectID  `bson:"exerciseID"`
+	Variation  string              `bson:"variation"`
+	Equipment  string              `bson:"equipment"`
+	MaxWeight  float64             `bson:"maxWeight"`
+	Reps       int                 `bson:"reps"`
+	Volume     float64             `bson:"volume"`
+	Sets       []models.WorkoutSet `bson:"sets"`
+}
+
+// WorkoutTotals is a workout with the totals of its exercises, in the order
+// they were first done.
+type WorkoutTotals struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	WorkoutDate primitive.DateTime `bson:"workoutDate"`
+	RoutineID   primitive.ObjectID `bson:"routineID"`
+	Exercises   []ExerciseTotals   `bson:"exercises"`
+}
+
+// GetWorkoutTotals totals the user's workouts with the given IDs, oldest
+// first. Workouts that don't exist or belong to someone else are omitted.
+func GetWorkoutTotals(userID primitive.ObjectID, workoutIDs []primitive.ObjectID) ([]WorkoutTotals, error) {
+	return aggregateWorkoutTotals(mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": bson.M{"$in": workoutIDs}, "userID": userID}}},
+	})
+}
+
+// GetRecentWorkoutTotals totals the user's last n workouts of a routine,
+// oldest first.
+func GetRecentWorkoutTotals(userID, routineID primitive.ObjectID, n int64) ([]WorkoutTotals, error) {
+	return aggregateWorkoutTotals(mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userID": userID, "routineID": routineID}}},
+		{{Key: "$sort", Value: bson.D{{Key: "workoutDate", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$limit", Value: n}},
+	})
+}
+
+// aggregateWorkoutTotals runs the workouts selected by head through the
+// totals stages: unwound into exercises, grouped per exercise and variation,
+// then regrouped per workout in exercise order.
+func aggregateWorkoutTotals(head mongo.Pipeline) (workouts []WorkoutTotals, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := GetCollection("workouts")
+
+	sets := bson.M{"$ifNull": bson.A{"$exercises.sets", bson.A{}}}
+	pipeline := append(head,
+		bson.D{{Key: "$unwind", Value: bson.M{
+			"path":                       "$exercises",
+			"includeArrayIndex":          "position",
+			"preserveNullAndEmptyArrays": true,
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}, {Key: "position", Value: 1}}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"workout":    "$_id",
+				"exerciseID": "$exercises.exerciseID",
+				"variation":  bson.M{"$ifNull": bson.A{"$exercises.variation", ""}},
+			},
+			"workoutDate": bson.M{"$first": "$workoutDate"},
+			"routineID":   bson.M{"$first": "$routineID"},
+			"position":    bson.M{"$min": "$position"},
+			"equipment":   bson.M{"$last": "$exercises.equipment"},
+			"reps":        bson.M{"$sum": bson.M{"$sum": "$exercises.sets.reps"}},
+			"volume": bson.M{"$sum": bson.M{"$sum": bson.M{"$map": bson.M{
+				"input": sets,
+				"as":    "s",
+				"in":    bson.M{"$multiply": bson.A{"$$s.reps", "$$s.weight"}},
+			}}}},
+			"maxWeight": bson.M{"$max": bson.M{"$max": "$exercises.sets.weight"}},
+			"sets":      bson.M{"$push": sets},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.M{"position": 1}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":         "$_id.workout",
+			"workoutDate": bson.M{"$first": "$workoutDate"},
+			"routineID":   bson.M{"$first": "$routineID"},
+			"exercises": bson.M{"$push": bson.M{
+				"exerciseID": "$_id.exerciseID",
+				"variation":  "$_id.variation",
+				"equipment":  bson.M{"$ifNull": bson.A{"$equipment", ""}},
+				"maxWeight":  bson.M{"$ifNull": bson.A{"$maxWeight", 0}},
+				"reps":       "$reps",
+				"volume":     "$volume",
+				"sets": bson.M{"$reduce": bson.M{
+					"input":        "$sets",
+					"initialValue": bson.A{},
+					"in":           bson.M{"$concatArrays": bson.A{"$$value", "$$this"}},
+				}},
+			}},
+		}}},
+		// Workouts without exercises keep one empty entry from the unwind
+		bson.D{{Key: "$addFields", Value: bson.M{"exercises": bson.M{"$filter": bson.M{
+			"input": "$exercises",
+			"cond":  bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$$this.exerciseID", nil}}, nil}},
+		}}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "workoutDate", Value: 1}, {Key: "_id", Value: 1}}}},
+	)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var workout WorkoutTotals
+		if err := cursor.Decode(&workout); err != nil {
+			log.Printf("Error decoding workout totals: %v", err)
+			continue
+		}
+		workouts = append(workouts, workout)
+	}
+
+	err = cursor.Err()
+	return
+}
@@ -98,3 +98,51 @@ func CreateExerciseHistory(history models.ExerciseHistory) (historyID primitive.
 	historyID = result.InsertedID.(primitive.ObjectID)
 	return
 }
+
+func CreateSessionArchive(session models.WorkoutSession) (archiveID primitive.ObjectID, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("sessionArchive")
+
+	// Archived copies get their own ID so a user can archive many sessions
+	session.ID = primitive.NilObjectID
+
+	result, err := collection.InsertOne(ctx, session)
+	if err != nil {
+		return
+	}
+
+	archiveID = result.InsertedID.(primitive.ObjectID)
+	return
+}
+
+func CreateMeasurement(measurement models.BodyMeasurement) (measurementID primitive.ObjectID, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("measurements")
+
+	result, err := collection.InsertOne(ctx, measurement)
+	if err != nil {
+		return
+	}
+
+	measurementID = result.InsertedID.(primitive.ObjectID)
+	return
+}
+
+func CreateGoal(goal models.Goal) (goalID primitive.ObjectID, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("goals")
+
+	result, err := collection.InsertOne(ctx, goal)
+	if err != nil {
+		return
+	}
+
+	goalID = result.InsertedID.(primitive.ObjectID)
+	return
+}
@@ -6,8 +6,11 @@ import (
 	"log"
 	"time"
 
+	"fitness-tracker/internal/models"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func DeleteSession(sessionID primitive.ObjectID) (err error) {
@@ -20,6 +23,23 @@ func DeleteSession(sessionID primitive.ObjectID) (err error) {
 	return
 }
 
+// ClaimExpiredSession removes the session if it is still expired and returns
+// it as it was at removal. Only one caller can claim a session, and one the
+// user touched since it was listed no longer matches, so it returns
+// mongo.ErrNoDocuments to everyone else.
+func ClaimExpiredSession(sessionID primitive.ObjectID, now, legacyCutoff time.Time) (session models.WorkoutSession, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("sessions")
+
+	filter := expiredSessionFilter(now, legacyCutoff)
+	filter["_id"] = sessionID
+
+	err = collection.FindOneAndDelete(ctx, filter).Decode(&session)
+	return
+}
+
 func DeleteRoutine(userID, routineID primitive.ObjectID) (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -43,3 +63,70 @@ func DeleteRoutine(userID, routineID primitive.ObjectID) (err error) {
 
 	return
 }
+
+func DeleteExercise(exerciseID primitive.ObjectID) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exercises")
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": exerciseID})
+	if err != nil {
+		log.Println("Failed to delete exercise")
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return
+}
+
+func DeleteExerciseHistory(historyID primitive.ObjectID) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exerciseHistory")
+
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": historyID})
+	return
+}
+
+func DeleteMeasurement(measurementID, userID primitive.ObjectID) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("measurements")
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": measurementID, "userID": userID})
+	if err != nil {
+		log.Println("Failed to delete measurement")
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return
+}
+
+func DeleteGoal(goalID, userID primitive.ObjectID) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("goals")
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": goalID, "userID": userID})
+	if err != nil {
+		log.Println("Failed to delete goal")
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return
+}
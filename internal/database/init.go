@@ -2,12 +2,17 @@ package database
 
 import (
 	"context"
+	"errors"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// CaseInsensitive is the collation used for catalog lookups so that "bench
+// press" finds "Bench Press". Queries must pass it to use the matching indexes.
+var CaseInsensitive = &options.Collation{Locale: "en", Strength: 2}
+
 func InitIndexes(ctx context.Context, db *mongo.Database) error {
 	if err := initUserIndexes(ctx, db); err != nil {
 		return err
@@ -27,6 +32,15 @@ func InitIndexes(ctx context.Context, db *mongo.Database) error {
 	if err := initExerciseHistoryIndexes(ctx, db); err != nil {
 		return err
 	}
+	if err := initEquipmentIndexes(ctx, db); err != nil {
+		return err
+	}
+	if err := initMeasurementIndexes(ctx, db); err != nil {
+		return err
+	}
+	if err := initGoalIndexes(ctx, db); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -55,10 +69,54 @@ func initRoutineIndexes(ctx context.Context, db *mongo.Database) error {
 func initExerciseIndexes(ctx context.Context, db *mongo.Database) error {
 	exercises := db.Collection("exercises")
 
-	// Create unique index on name (idempotent if it already exists uniquely)
-	_, err := exercises.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D{{Key: "name", Value: 1}},
-		Options: options.Index().SetUnique(true),
+	// Names used to be globally unique; they are now unique per owner so that
+	// custom exercises don't collide with the catalog or each other.
+	if _, err := exercises.Indexes().DropOne(ctx, "name_1"); err != nil && !isIndexNotFound(err) {
+		return err
+	}
+	// Slugs used to be unique across all exercises, so a private exercise
+	// could take a catalog slug and break the catalog sync.
+	if _, err := exercises.Indexes().DropOne(ctx, "slug_1"); err != nil && !isIndexNotFound(err) {
+		return err
+	}
+
+	_, err := exercises.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "ownerID", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetName("owner_name_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetName("name_ci").SetCollation(CaseInsensitive),
+		},
+		{
+			// Partial filters can't select a missing ownerID, so the owner is
+			// part of the key: slugs are unique within the global catalog,
+			// where ownerID is unset, and never clash with private exercises.
+			Keys: bson.D{{Key: "slug", Value: 1}, {Key: "ownerID", Value: 1}},
+			Options: options.Index().SetName("slug_owner_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"slug": bson.M{"$exists": true}}),
+		},
+		{
+			Keys:    bson.D{{Key: "aliases", Value: 1}},
+			Options: options.Index().SetCollation(CaseInsensitive),
+		},
+		{
+			Keys:    bson.D{{Key: "category", Value: 1}},
+			Options: options.Index().SetCollation(CaseInsensitive),
+		},
+		{
+			Keys:    bson.D{{Key: "equipment", Value: 1}},
+			Options: options.Index().SetCollation(CaseInsensitive),
+		},
+		{
+			Keys:    bson.D{{Key: "primaryMuscles", Value: 1}},
+			Options: options.Index().SetCollation(CaseInsensitive),
+		},
+		{
+			Keys:    bson.D{{Key: "movementPattern", Value: 1}},
+			Options: options.Index().SetCollation(CaseInsensitive),
+		},
 	})
 	return err
 }
@@ -67,19 +125,46 @@ func initExerciseIndexes(ctx context.Context, db *mongo.Database) error {
 
 func initSessionIndexes(ctx context.Context, db *mongo.Database) error {
 	sessions := db.Collection("sessions")
+
+	// Expiry used to be a 48h TTL index on lastUpdated, which silently deleted
+	// sessions. Drop it so expired sessions can be finished or archived instead.
+	if _, err := sessions.Indexes().DropOne(ctx, "lastUpdated_1"); err != nil && !isIndexNotFound(err) {
+		return err
+	}
+
 	_, err := sessions.Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{
 			Keys:    bson.D{{Key: "userID", Value: 1}},
 			Options: options.Index().SetUnique(true),
 		},
 		{
-			Keys:    bson.D{{Key: "lastUpdated", Value: 1}},
-			Options: options.Index().SetExpireAfterSeconds(60 * 60 * 48), // TTL: 48 hours
+			Keys: bson.D{{Key: "expiresAt", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "lastUpdated", Value: 1}},
 		},
 	})
+	if err != nil {
+		return err
+	}
+
+	archive := db.Collection("sessionArchive")
+	_, err = archive.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userID", Value: 1}, {Key: "archivedAt", Value: -1}},
+	})
 	return err
 }
 
+// isIndexNotFound reports whether err is the server's IndexNotFound (27) or
+// NamespaceNotFound (26) error, both of which are harmless when dropping.
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 27 || cmdErr.Code == 26
+	}
+	return false
+}
+
 func initWorkoutIndexes(ctx context.Context, db *mongo.Database) error {
 	workouts := db.Collection("workouts")
 	_, err := workouts.Indexes().CreateMany(ctx, []mongo.IndexModel{
@@ -92,6 +177,12 @@ func initWorkoutIndexes(ctx context.Context, db *mongo.Database) error {
 		{
 			Keys: bson.D{{Key: "userID", Value: 1}, {Key: "routineID", Value: 1}, {Key: "workoutDate", Value: -1}},
 		},
+		{
+			Keys: bson.D{{Key: "userID", Value: 1}, {Key: "tags", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "userID", Value: 1}, {Key: "exercises.exerciseID", Value: 1}, {Key: "workoutDate", Value: -1}},
+		},
 	})
 	return err
 }
@@ -109,4 +200,29 @@ func initExerciseHistoryIndexes(ctx context.Context, db *mongo.Database) error {
 	return err
 }
 
+func initEquipmentIndexes(ctx context.Context, db *mongo.Database) error {
+	equipment := db.Collection("equipmentInventory")
+	_, err := equipment.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userID", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func initMeasurementIndexes(ctx context.Context, db *mongo.Database) error {
+	measurements := db.Collection("measurements")
+	_, err := measurements.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userID", Value: 1}, {Key: "date", Value: 1}},
+	})
+	return err
+}
+
+func initGoalIndexes(ctx context.Context, db *mongo.Database) error {
+	goals := db.Collection("goals")
+	_, err := goals.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userID", Value: 1}, {Key: "status", Value: 1}},
+	})
+	return err
+}
+
 // Cardio feature removed
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UnitMigrationReport counts what MigrateWeightsToKg converted.
+type UnitMigrationReport struct {
+	DryRun    bool  `json:"dry_run"`
+	Users     int   `json:"users"`
+	Converted int   `json:"converted_users"`
+	Workouts  int64 `json:"workouts"`
+	Sessions  int64 `json:"sessions"`
+	Histories int64 `json:"histories"`
+}
+
+// CountPendingWeightMigration counts the users whose data predates kilogram
+// storage.
+func CountPendingWeightMigration(ctx context.Context, db *mongo.Database) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return db.Collection("users").CountDocuments(ctx, bson.M{"weightStorage": bson.M{"$ne": units.Kg}})
+}
+
+// MigrateWeightsToKg converts the stored weights of every user whose data
+// predates kilogram storage. Users preferring pounds have their bodyweight and
+// every set weight in workouts, sessions, archived sessions and exercise
+// history divided into kilograms. Each document is marked as converted in the
+// same write and skipped afterwards, and users are marked once all their
+// documents are done, so a run that fails partway can simply be repeated.
+// With dryRun nothing is written.
+func MigrateWeightsToKg(ctx context.Context, db *mongo.Database, dryRun bool) (*UnitMigrationReport, error) {
+	users := db.Collection("users")
+
+	findCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := users.Find(findCtx, bson.M{"weightStorage": bson.M{"$ne": units.Kg}})
+	if err != nil {
+		return nil, err
+	}
+	var pending []models.User
+	if err := cursor.All(findCtx, &pending); err != nil {
+		return nil, err
+	}
+
+	report := &UnitMigrationReport{DryRun: dryRun, Users: len(pending)}
+
+	for _, user := range pending {
+		if units.Normalize(user.UnitPreference) == units.Lb {
+			report.Converted++
+
+			for _, name := range []string{"workouts", "sessions", "sessionArchive"} {
+				n, err := convertExerciseWeights(ctx, db.Collection(name), user.ID, dryRun)
+				if err != nil {
+					return nil, err
+				}
+				if name == "workouts" {
+					report.Workouts += n
+				} else {
+					report.Sessions += n
+				}
+			}
+
+			n, err := convertHistoryWeights(ctx, db.Collection("exerciseHistory"), user.ID, dryRun)
+			if err != nil {
+				return nil, err
+			}
+			report.Histories += n
+		}
+
+		if dryRun {
+			continue
+		}
+
+		updates := bson.M{"weightStorage": units.Kg}
+		if units.Normalize(user.UnitPreference) == units.Lb {
+			updates["weight"] = units.ToKg(user.Weight, units.Lb)
+		}
+
+		updateCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		_, err := users.UpdateOne(updateCtx, bson.M{"_id": user.ID}, bson.M{"$set": updates})
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// unconverted matches the user's documents not yet converted to kilograms.
+func unconverted(userID primitive.ObjectID) bson.M {
+	return bson.M{"userID": userID, "weightStorage": bson.M{"$ne": units.Kg}}
+}
+
+// convertExerciseWeights rewrites the set weights and bodyweight of the user's
+// documents in a workouts-shaped collection from pounds to kilograms.
+func convertExerciseWeights(ctx context.Context, collection *mongo.Collection, userID primitive.ObjectID, dryRun bool) (converted int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, unconverted(userID))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID        primitive.ObjectID       `bson:"_id"`
+			Exercises []models.WorkoutExercise `bson:"exercises"`
+			Metadata  *models.WorkoutMetadata  `bson:"metadata"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return converted, err
+		}
+
+		for i := range doc.Exercises {
+			for j := range doc.Exercises[i].Sets {
+				doc.Exercises[i].Sets[j].Weight = units.ToKg(doc.Exercises[i].Sets[j].Weight, units.Lb)
+			}
+		}
+		updates := bson.M{"exercises": doc.Exercises, "weightStorage": units.Kg}
+		if doc.Metadata != nil {
+			updates["metadata.bodyweight"] = units.ToKg(doc.Metadata.Bodyweight, units.Lb)
+		}
+
+		converted++
+		if dryRun {
+			continue
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": updates}); err != nil {
+			return converted, err
+		}
+	}
+
+	return converted, cursor.Err()
+}
+
+// convertHistoryWeights rewrites the user's exercise history weights from
+// pounds to kilograms.
+func convertHistoryWeights(ctx context.Context, collection *mongo.Collection, userID primitive.ObjectID, dryRun bool) (converted int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, unconverted(userID))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var history models.ExerciseHistory
+		if err := cursor.Decode(&history); err != nil {
+			return converted, err
+		}
+
+		for i := range history.Sets {
+			for j := range history.Sets[i].WorkoutSets {
+				history.Sets[i].WorkoutSets[j].Weight = units.ToKg(history.Sets[i].WorkoutSets[j].Weight, units.Lb)
+			}
+		}
+
+		converted++
+		if dryRun {
+			continue
+		}
+		_, err := collection.UpdateOne(ctx, bson.M{"_id": history.ID}, bson.M{"$set": bson.M{"exerciseSets": history.Sets, "weightStorage": units.Kg}})
+		if err != nil {
+			return converted, err
+		}
+	}
+
+	return converted, cursor.Err()
+}
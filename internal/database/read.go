@@ -2,13 +2,20 @@ package database
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"log"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"fitness-tracker/internal/config"
 	"fitness-tracker/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -109,61 +116,193 @@ func GetRoutineData(userID, routineID primitive.ObjectID) (routine models.FullRo
 	return
 }
 
-func GetUserWorkouts(userID primitive.ObjectID) (workoutList []models.Workout, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// GetUserWorkouts returns all of the user's workouts, newest first, limited
+// to one routine unless routineID is zero.
+func GetUserWorkouts(userID, routineID primitive.ObjectID) (workoutList []models.Workout, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	collection := GetCollection("workouts")
 
-	cursor, err := collection.Find(ctx, bson.M{
-		"userID": userID,
-	})
+	filter := bson.M{"userID": userID}
+	if !routineID.IsZero() {
+		filter["routineID"] = routineID
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "workoutDate", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := collection.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, err
+		return
 	}
+	defer cursor.Close(ctx)
 
 	for cursor.Next(ctx) {
 		var workout models.Workout
 		if err := cursor.Decode(&workout); err != nil {
+			log.Printf("Error decoding workout document: %v", err)
 			continue
 		}
 		workoutList = append(workoutList, workout)
 	}
 
-	if err := cursor.Err(); err != nil {
-		log.Println("Error decoding some documents")
-		log.Println(err)
+	err = cursor.Err()
+	return
+}
+
+// WorkoutListFilter narrows a user's workout list. Zero values are ignored.
+type WorkoutListFilter struct {
+	RoutineID  primitive.ObjectID
+	ExerciseID primitive.ObjectID
+	From       time.Time
+	To         time.Time
+	Tags       []string
+	Query      string
+}
+
+// WorkoutCursor marks the last workout of a page; the next page starts
+// strictly after it in (workoutDate, _id) descending order.
+type WorkoutCursor struct {
+	WorkoutDate primitive.DateTime
+	ID          primitive.ObjectID
+}
+
+// Encode renders the cursor as an opaque URL-safe token.
+func (c WorkoutCursor) Encode() string {
+	raw := strconv.FormatInt(int64(c.WorkoutDate), 10) + "_" + c.ID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeWorkoutCursor parses a token produced by WorkoutCursor.Encode.
+func DecodeWorkoutCursor(token string) (cursor WorkoutCursor, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return
 	}
 
-	return
+	millis, hex, found := strings.Cut(string(raw), "_")
+	if !found {
+		return cursor, errors.New("malformed workout cursor")
+	}
+
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return
+	}
+
+	return WorkoutCursor{WorkoutDate: primitive.DateTime(ms), ID: id}, nil
 }
 
-func GetUserWorkoutsByRoutine(userID, routineID primitive.ObjectID) (workoutList []models.Workout, err error) {
+// ListUserWorkouts returns one page of workout summaries, newest first. Query
+// is a case-insensitive substring match on workout, exercise and set notes and
+// on the recorded location; every tag in Tags must be present. The returned
+// cursor is nil on the last page.
+func ListUserWorkouts(userID primitive.ObjectID, filter WorkoutListFilter, after *WorkoutCursor, limit int) (workoutList []models.WorkoutSummary, next *WorkoutCursor, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	collection := GetCollection("workouts")
 
-	cursor, err := collection.Find(ctx, bson.M{
-		"userID":    userID,
-		"routineID": routineID,
-	})
+	query := bson.M{"userID": userID}
+	if !filter.RoutineID.IsZero() {
+		query["routineID"] = filter.RoutineID
+	}
+	if !filter.ExerciseID.IsZero() {
+		query["exercises.exerciseID"] = filter.ExerciseID
+	}
+	if len(filter.Tags) > 0 {
+		query["tags"] = bson.M{"$all": filter.Tags}
+	}
+
+	dateRange := bson.M{}
+	if !filter.From.IsZero() {
+		dateRange["$gte"] = primitive.NewDateTimeFromTime(filter.From)
+	}
+	if !filter.To.IsZero() {
+		dateRange["$lt"] = primitive.NewDateTimeFromTime(filter.To)
+	}
+	if len(dateRange) > 0 {
+		query["workoutDate"] = dateRange
+	}
+
+	var and []bson.M
+	if filter.Query != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Query), Options: "i"}
+		and = append(and, bson.M{"$or": []bson.M{
+			{"notes": pattern},
+			{"exercises.notes": pattern},
+			{"exercises.sets.notes": pattern},
+			{"metadata.location": pattern},
+		}})
+	}
+	if after != nil {
+		and = append(and, bson.M{"$or": []bson.M{
+			{"workoutDate": bson.M{"$lt": after.WorkoutDate}},
+			{"workoutDate": after.WorkoutDate, "_id": bson.M{"$lt": after.ID}},
+		}})
+	}
+	if len(and) > 0 {
+		query["$and"] = and
+	}
+
+	exerciseVolume := bson.M{"$sum": bson.M{"$map": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$$e.sets", bson.A{}}},
+		"as":    "s",
+		"in":    bson.M{"$multiply": bson.A{"$$s.reps", "$$s.weight"}},
+	}}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: query}},
+		{{Key: "$sort", Value: bson.D{{Key: "workoutDate", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$limit", Value: limit + 1}},
+		{{Key: "$project", Value: bson.M{
+			"userID":        1,
+			"routineID":     1,
+			"workoutDate":   1,
+			"localStart":    1,
+			"partial":       1,
+			"notes":         1,
+			"tags":          1,
+			"exerciseCount": bson.M{"$size": bson.M{"$ifNull": bson.A{"$exercises", bson.A{}}}},
+			"totalVolume": bson.M{"$sum": bson.M{"$map": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$exercises", bson.A{}}},
+				"as":    "e",
+				"in":    exerciseVolume,
+			}}},
+			"durationSeconds": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$startedAt", nil}},
+				bson.M{"$toLong": bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{"$workoutDate", "$startedAt"}}, 1000}}},
+				0,
+			}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer cursor.Close(ctx)
 
 	for cursor.Next(ctx) {
-		var workout models.Workout
+		var workout models.WorkoutSummary
 		if err := cursor.Decode(&workout); err != nil {
+			log.Printf("Error decoding workout summary: %v", err)
 			continue
 		}
 		workoutList = append(workoutList, workout)
 	}
 
 	if err := cursor.Err(); err != nil {
-		log.Println("Error decoding some documents")
-		log.Println(err)
+		return nil, nil, err
+	}
+
+	if len(workoutList) > limit {
+		workoutList = workoutList[:limit]
+		last := workoutList[limit-1]
+		next = &WorkoutCursor{WorkoutDate: last.WorkoutDate, ID: last.ID}
 	}
 
 	return
@@ -237,31 +376,411 @@ func GetSessionData(sessionID primitive.ObjectID) (session models.WorkoutSession
 	return
 }
 
-func GetExerciseList() (exercises []models.Exercise) {
+// GetExpiredSessions returns sessions whose expiresAt has passed, plus legacy
+// sessions without expiresAt that have not been touched since legacyCutoff.
+func GetExpiredSessions(now, legacyCutoff time.Time) (sessions []models.WorkoutSession, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("sessions")
+
+	cursor, err := collection.Find(ctx, expiredSessionFilter(now, legacyCutoff))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var session models.WorkoutSession
+		if err := cursor.Decode(&session); err != nil {
+			log.Printf("Error decoding session document: %v", err)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// expiredSessionFilter matches sessions past their expiry, or, for sessions
+// from before expiries were stored, last updated before legacyCutoff.
+func expiredSessionFilter(now, legacyCutoff time.Time) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			{"expiresAt": bson.M{"$lte": primitive.NewDateTimeFromTime(now)}},
+			{
+				"expiresAt":   bson.M{"$exists": false},
+				"lastUpdated": bson.M{"$lte": primitive.NewDateTimeFromTime(legacyCutoff)},
+			},
+		},
+	}
+}
+
+// ExerciseFilter narrows the exercise catalog. Matching is case-insensitive;
+// an exercise must offer every listed piece of equipment. Only global
+// exercises are returned unless OwnerID is set, in which case that user's
+// custom exercises are merged in.
+type ExerciseFilter struct {
+	OwnerID         primitive.ObjectID
+	Category        string
+	Equipment       []string
+	Variation       string
+	Muscle          string // primary or secondary
+	PrimaryMuscle   string
+	MovementPattern string
+	Force           string
+	Mechanic        string
+	IncludeArchived bool
+}
+
+// SearchExercises returns the catalog exercises matching the filter, sorted
+// by name.
+func SearchExercises(filter ExerciseFilter) (exercises []models.Exercise, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	collection := GetCollection("exercises")
 
-	cursor, err := collection.Find(ctx, bson.M{})
+	query := exerciseQuery(filter)
+	opts := options.Find().SetCollation(CaseInsensitive).SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := collection.Find(ctx, query, opts)
 	if err != nil {
-		return nil
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
 	for cursor.Next(ctx) {
 		var exercise models.Exercise
 		if err := cursor.Decode(&exercise); err != nil {
+			log.Printf("Error decoding exercise document: %v", err)
 			continue
 		}
 		exercises = append(exercises, exercise)
 	}
 
+	err = cursor.Err()
+	return
+}
+
+// exerciseQuery builds the Find filter for an ExerciseFilter. Run it with the
+// CaseInsensitive collation so that it can use the catalog indexes.
+func exerciseQuery(filter ExerciseFilter) bson.M {
+	query := bson.M{}
+	and := []bson.M{ownerScope(filter.OwnerID)}
+	if !filter.IncludeArchived {
+		query["archived"] = bson.M{"$ne": true}
+	}
+	if filter.Category != "" {
+		query["category"] = filter.Category
+	}
+	if len(filter.Equipment) > 0 {
+		query["equipment"] = bson.M{"$all": filter.Equipment}
+	}
+	if filter.Variation != "" {
+		query["variations"] = filter.Variation
+	}
+	if filter.Muscle != "" {
+		and = append(and, bson.M{"$or": []bson.M{
+			{"primaryMuscles": filter.Muscle},
+			{"secondaryMuscles": filter.Muscle},
+		}})
+	}
+	if filter.PrimaryMuscle != "" {
+		query["primaryMuscles"] = filter.PrimaryMuscle
+	}
+	if filter.MovementPattern != "" {
+		query["movementPattern"] = filter.MovementPattern
+	}
+	if filter.Force != "" {
+		query["force"] = filter.Force
+	}
+	if filter.Mechanic != "" {
+		query["mechanic"] = filter.Mechanic
+	}
+	query["$and"] = and
+	return query
+}
+
+// Catalog sort orders.
+const (
+	SortRelevance = "relevance"
+	SortName      = "name"
+	SortNameDesc  = "-name"
+	SortCategory  = "category"
+)
+
+// CatalogSearch is a page of a catalog search. Text is matched, ignoring
+// case, as a substring of the names, aliases and variations in every
+// supported locale; IDs, when set, restricts the search to those exercises.
+// Facet values are labelled in the first of Locales that translates them.
+type CatalogSearch struct {
+	Filter  ExerciseFilter
+	Text    string
+	IDs     []primitive.ObjectID
+	Sort    string
+	Offset  int
+	Limit   int
+	Locales []string
+}
+
+// FacetCount is how many results share a category or piece of equipment.
+// Value is the canonical English value filters take; Label is the value as
+// localized exercises show it, only set when a locale was requested.
+type FacetCount struct {
+	Value string `bson:"_id" json:"value"`
+	Label string `bson:"label" json:"label,omitempty"`
+	Count int    `bson:"count" json:"count"`
+}
+
+// CatalogPage is one page of search results with the total number of matches
+// and the facet counts over all of them.
+type CatalogPage struct {
+	Exercises  []models.Exercise
+	Total      int
+	Categories []FacetCount
+	Equipment  []FacetCount
+}
+
+// SearchCatalog runs a catalog search as a single aggregation: the shared
+// filters and text match first, then a $facet that pages the results and
+// counts them, with category counts ignoring the category filter and
+// equipment counts ignoring the equipment filter.
+func SearchCatalog(search CatalogSearch) (page CatalogPage, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exercises")
+
+	// Category and equipment are matched inside the facets so that each can
+	// be counted without its own filter
+	base := search.Filter
+	base.Category = ""
+	base.Equipment = nil
+	match := exerciseQuery(base)
+	if len(search.IDs) > 0 {
+		match["_id"] = bson.M{"$in": search.IDs}
+	}
+
+	byCategory, byEquipment := bson.M{}, bson.M{}
+	if search.Filter.Category != "" {
+		byCategory["category"] = search.Filter.Category
+	}
+	if len(search.Filter.Equipment) > 0 {
+		byEquipment["equipment"] = bson.M{"$all": search.Filter.Equipment}
+	}
+	selected := bson.M{"$and": []bson.M{byCategory, byEquipment}}
+
+	pipeline := mongo.Pipeline{}
+	if search.Text != "" {
+		pattern := regexp.QuoteMeta(search.Text)
+		fields := catalogTextFields()
+		or := make([]bson.M, 0, len(fields))
+		for _, field := range fields {
+			or = append(or, bson.M{field: primitive.Regex{Pattern: pattern, Options: "i"}})
+		}
+		match["$and"] = append(match["$and"].([]bson.M), bson.M{"$or": or})
+
+		pipeline = append(pipeline,
+			bson.D{{Key: "$match", Value: match}},
+			bson.D{{Key: "$addFields", Value: bson.M{"score": catalogScore(pattern)}}},
+		)
+	} else {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: match}})
+	}
+
+	results := bson.A{
+		bson.M{"$match": selected},
+		bson.M{"$sort": catalogSort(search.Sort)},
+		bson.M{"$skip": search.Offset},
+	}
+	if search.Limit > 0 {
+		results = append(results, bson.M{"$limit": search.Limit})
+	}
+	results = append(results, bson.M{"$project": bson.M{"score": 0}})
+
+	facetOrder := bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: bson.M{
+		"results": results,
+		"total":   bson.A{bson.M{"$match": selected}, bson.M{"$count": "n"}},
+		"category": append(bson.A{
+			bson.M{"$match": bson.M{"$and": []bson.M{byEquipment, {"category": bson.M{"$nin": bson.A{"", nil}}}}}},
+			bson.M{"$group": facetGroup("$category", search.Locales, func(locale string) any {
+				return "$translations." + locale + ".category"
+			})},
+		}, facetLabel(search.Locales, facetOrder)...),
+		"equipment": append(bson.A{
+			bson.M{"$match": byCategory},
+			bson.M{"$unwind": "$equipment"},
+			bson.M{"$group": facetGroup("$equipment", search.Locales, func(locale string) any {
+				return translatedValue("$equipment", "$translations."+locale+".equipment")
+			})},
+		}, facetLabel(search.Locales, facetOrder)...),
+	}}})
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetCollation(CaseInsensitive))
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var facets struct {
+		Results   []models.Exercise `bson:"results"`
+		Total     []struct{ N int } `bson:"total"`
+		Category  []FacetCount      `bson:"category"`
+		Equipment []FacetCount      `bson:"equipment"`
+	}
+	if cursor.Next(ctx) {
+		if err = cursor.Decode(&facets); err != nil {
+			return
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return
+	}
+
+	page = CatalogPage{
+		Exercises:  facets.Results,
+		Categories: facets.Category,
+		Equipment:  facets.Equipment,
+	}
+	if len(facets.Total) > 0 {
+		page.Total = facets.Total[0].N
+	}
+	if page.Exercises == nil {
+		page.Exercises = []models.Exercise{}
+	}
+	if page.Categories == nil {
+		page.Categories = []FacetCount{}
+	}
+	if page.Equipment == nil {
+		page.Equipment = []FacetCount{}
+	}
 	return
 }
 
+// facetGroup counts the documents per value of key and, for each locale,
+// keeps a translation of the value as l0, l1 and so on.
+func facetGroup(key string, locales []string, translation func(locale string) any) bson.M {
+	group := bson.M{"_id": key, "count": bson.M{"$sum": 1}}
+	for i, locale := range locales {
+		group["l"+strconv.Itoa(i)] = bson.M{"$max": translation(locale)}
+	}
+	return group
+}
+
+// facetLabel labels grouped facet values with their first translation,
+// falling back to the value itself, before sorting them.
+func facetLabel(locales []string, order bson.M) bson.A {
+	if len(locales) == 0 {
+		return bson.A{order}
+	}
+	labels := make(bson.A, 0, len(locales)+1)
+	for i := range locales {
+		labels = append(labels, "$l"+strconv.Itoa(i))
+	}
+	labels = append(labels, "$_id")
+	return bson.A{bson.M{"$addFields": bson.M{"label": bson.M{"$ifNull": labels}}}, order}
+}
+
+// translatedValue looks value up in a map of canonical to translated values,
+// such as a translation's equipment, giving null when it has no entry.
+func translatedValue(value, translations string) bson.M {
+	return bson.M{"$let": bson.M{
+		"vars": bson.M{"hits": bson.M{"$filter": bson.M{
+			"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{translations, bson.M{}}}},
+			"cond":  bson.M{"$eq": bson.A{"$$this.k", value}},
+		}}},
+		"in": bson.M{"$arrayElemAt": bson.A{"$$hits.v", 0}},
+	}}
+}
+
+// catalogTextFields are the fields a catalog text search looks in.
+func catalogTextFields() []string {
+	fields := []string{"name", "aliases", "variations"}
+	for _, locale := range config.AppConfig.SupportedLocales {
+		if locale == config.DefaultLocale {
+			continue
+		}
+		fields = append(fields, "translations."+locale+".name", "translations."+locale+".aliases")
+	}
+	return fields
+}
+
+// catalogScore ranks a text match by its best hit: the whole name, a name
+// prefix, the start of a word in the name, or anywhere in it. Alias hits rank
+// just below name hits of the same kind and variation hits at half.
+func catalogScore(pattern string) bson.M {
+	names := bson.A{bson.A{"$name"}}
+	aliases := bson.A{bson.M{"$ifNull": bson.A{"$aliases", bson.A{}}}}
+	for _, locale := range config.AppConfig.SupportedLocales {
+		if locale == config.DefaultLocale {
+			continue
+		}
+		names = append(names, bson.A{bson.M{"$ifNull": bson.A{"$translations." + locale + ".name", ""}}})
+		aliases = append(aliases, bson.M{"$ifNull": bson.A{"$translations." + locale + ".aliases", bson.A{}}})
+	}
+
+	best := func(texts any) bson.M {
+		return bson.M{"$max": bson.M{"$map": bson.M{
+			"input": texts,
+			"as":    "t",
+			"in":    textScore("$$t", pattern),
+		}}}
+	}
+	return bson.M{"$max": bson.A{
+		best(bson.M{"$concatArrays": names}),
+		bson.M{"$subtract": bson.A{best(bson.M{"$concatArrays": aliases}), 5}},
+		bson.M{"$divide": bson.A{best(bson.M{"$ifNull": bson.A{"$variations", bson.A{}}}), 2}},
+	}}
+}
+
+func textScore(text, pattern string) bson.M {
+	matches := func(regex string) bson.M {
+		return bson.M{"$regexMatch": bson.M{"input": text, "regex": regex, "options": "i"}}
+	}
+	return bson.M{"$switch": bson.M{
+		"branches": bson.A{
+			bson.M{"case": matches("^" + pattern + "$"), "then": 100},
+			bson.M{"case": matches("^" + pattern), "then": 80},
+			bson.M{"case": matches(`[\s\-/(),]` + pattern), "then": 60},
+			bson.M{"case": matches(pattern), "then": 40},
+		},
+		"default": 0,
+	}}
+}
+
+// catalogSort orders results; relevance falls back to name order when there
+// is no text to score.
+func catalogSort(order string) bson.D {
+	switch order {
+	case SortRelevance:
+		return bson.D{{Key: "score", Value: -1}, {Key: "name", Value: 1}}
+	case SortNameDesc:
+		return bson.D{{Key: "name", Value: -1}}
+	case SortCategory:
+		return bson.D{{Key: "category", Value: 1}, {Key: "name", Value: 1}}
+	default:
+		return bson.D{{Key: "name", Value: 1}}
+	}
+}
+
 // Cardio feature removed
 
+// GetExerciseID resolves a global catalog exercise by name, ignoring case.
 func GetExerciseID(exerciseName string) (exerciseID string, err error) {
+	return GetExerciseIDForUser(exerciseName, primitive.NilObjectID)
+}
+
+// GetExerciseIDForUser resolves an exercise by name or alias, in English or any
+// supported locale, ignoring case, preferring the user's own custom exercise over a global one.
+// Merging keeps the source's name as an alias of the target, so names of
+// merged-away exercises still resolve here.
+func GetExerciseIDForUser(exerciseName string, userID primitive.ObjectID) (exerciseID string, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -269,9 +788,22 @@ func GetExerciseID(exerciseName string) (exerciseID string, err error) {
 
 	var exercise models.Exercise
 
+	// ObjectIDs sort after missing fields, so descending puts the user's first
+	opts := options.FindOne().SetCollation(CaseInsensitive).SetSort(bson.D{{Key: "ownerID", Value: -1}})
+	names := []bson.M{{"name": exerciseName}, {"aliases": exerciseName}}
+	for _, locale := range config.AppConfig.SupportedLocales {
+		if locale == config.DefaultLocale {
+			continue
+		}
+		names = append(names,
+			bson.M{"translations." + locale + ".name": exerciseName},
+			bson.M{"translations." + locale + ".aliases": exerciseName},
+		)
+	}
+
 	err = collection.FindOne(ctx, bson.M{
-		"name": exerciseName,
-	}).Decode(&exercise)
+		"$and": []bson.M{ownerScope(userID), {"$or": names}},
+	}, opts).Decode(&exercise)
 
 	if err != nil {
 		// Not found or other error; return empty ID with error
@@ -284,62 +816,292 @@ func GetExerciseID(exerciseName string) (exerciseID string, err error) {
 	return exercise.ID.Hex(), nil
 }
 
-func GetExerciseName(exerciseID primitive.ObjectID) (exerciseName string, err error) {
+// ownerScope matches global exercises plus, when userID is set, the user's
+// own custom ones.
+func ownerScope(userID primitive.ObjectID) bson.M {
+	global := bson.M{"ownerID": bson.M{"$exists": false}}
+	if userID.IsZero() {
+		return global
+	}
+	return bson.M{"$or": []bson.M{global, {"ownerID": userID}}}
+}
+
+func GetExerciseData(exerciseID primitive.ObjectID) (exercise models.Exercise, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	collection := GetCollection("exercises")
 
-	var exercise models.Exercise
-
 	err = collection.FindOne(ctx, bson.M{
 		"_id": exerciseID,
 	}).Decode(&exercise)
 
-	exerciseName = exercise.Name
-
 	return
 }
 
-func GetExerciseData(exerciseID primitive.ObjectID) (exercise models.Exercise, err error) {
+func GetExerciseHistoryData(exerciseID primitive.ObjectID, userID primitive.ObjectID) (history models.ExerciseHistory, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	collection := GetCollection("exercises")
+	collection := GetCollection("exerciseHistory")
 
 	err = collection.FindOne(ctx, bson.M{
-		"_id": exerciseID,
-	}).Decode(&exercise)
+		"exerciseID": exerciseID,
+		"userID":     userID,
+	}).Decode(&history)
 
 	return
 }
 
-func GetExerciseHistoryData(exerciseID primitive.ObjectID, userID primitive.ObjectID) (history models.ExerciseHistory, err error) {
+// GetUserHistoryExerciseIDs returns the IDs of every exercise the user has
+// history for.
+func GetUserHistoryExerciseIDs(userID primitive.ObjectID) (exerciseIDs []primitive.ObjectID, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	collection := GetCollection("exerciseHistory")
 
-	err = collection.FindOne(ctx, bson.M{
-		"exerciseID": exerciseID,
-		"userID":     userID,
-	}).Decode(&history)
+	values, err := collection.Distinct(ctx, "exerciseID", bson.M{"userID": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			exerciseIDs = append(exerciseIDs, id)
+		}
+	}
+
+	return
+}
+
+// ExerciseReferences counts the documents that point at an exercise. Users is
+// the number of distinct users across all of them.
+type ExerciseReferences struct {
+	Routines  int64 `json:"routines"`
+	Workouts  int64 `json:"workouts"`
+	Sessions  int64 `json:"sessions"`
+	Archived  int64 `json:"archived_sessions"`
+	Histories int64 `json:"histories"`
+	Goals     int64 `json:"goals"`
+	Users     int   `json:"users"`
+}
+
+// Total is the number of referencing documents.
+func (r ExerciseReferences) Total() int64 {
+	return r.Routines + r.Workouts + r.Sessions + r.Archived + r.Histories + r.Goals
+}
+
+// CountExerciseReferences reports how many routines, workouts, sessions,
+// archived sessions, histories and goals reference the exercise, and how many
+// users own them.
+func CountExerciseReferences(exerciseID primitive.ObjectID) (refs ExerciseReferences, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	users := map[primitive.ObjectID]struct{}{}
+	count := func(collectionName, field string, total *int64) error {
+		collection := GetCollection(collectionName)
+		filter := bson.M{field: exerciseID}
 
+		n, err := collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return err
+		}
+		*total = n
+		if n == 0 {
+			return nil
+		}
+
+		values, err := collection.Distinct(ctx, "userID", filter)
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			if id, ok := v.(primitive.ObjectID); ok {
+				users[id] = struct{}{}
+			}
+		}
+		return nil
+	}
+
+	if err = count("routines", "exercises.exerciseID", &refs.Routines); err != nil {
+		return
+	}
+	if err = count("workouts", "exercises.exerciseID", &refs.Workouts); err != nil {
+		return
+	}
+	if err = count("sessions", "exercises.exerciseID", &refs.Sessions); err != nil {
+		return
+	}
+	if err = count("sessionArchive", "exercises.exerciseID", &refs.Archived); err != nil {
+		return
+	}
+	if err = count("exerciseHistory", "exerciseID", &refs.Histories); err != nil {
+		return
+	}
+	if err = count("goals", "exerciseID", &refs.Goals); err != nil {
+		return
+	}
+
+	refs.Users = len(users)
+	return
+}
+
+// GetExerciseHistoriesForExercise returns every user's history for an
+// exercise.
+func GetExerciseHistoriesForExercise(exerciseID primitive.ObjectID) (histories []models.ExerciseHistory, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exerciseHistory")
+
+	cursor, err := collection.Find(ctx, bson.M{"exerciseID": exerciseID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var history models.ExerciseHistory
+		if err := cursor.Decode(&history); err != nil {
+			log.Printf("Error decoding history document: %v", err)
+			continue
+		}
+		histories = append(histories, history)
+	}
+
+	err = cursor.Err()
+	return
+}
+
+// GetUserExerciseHistories returns the user's history for every exercise.
+func GetUserExerciseHistories(userID primitive.ObjectID) (histories []models.ExerciseHistory, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exerciseHistory")
+
+	cursor, err := collection.Find(ctx, bson.M{"userID": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var history models.ExerciseHistory
+		if err := cursor.Decode(&history); err != nil {
+			log.Printf("Error decoding history document: %v", err)
+			continue
+		}
+		histories = append(histories, history)
+	}
+
+	err = cursor.Err()
+	return
+}
+
+// GetBestWeightsByReps returns, per exercise, the heaviest weight lifted for
+// every rep count from 1 to maxReps in the user's workouts before the given
+// time.
+func GetBestWeightsByReps(userID primitive.ObjectID, exerciseIDs []primitive.ObjectID, before time.Time, maxReps int) (best map[primitive.ObjectID]map[int]float64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := GetCollection("workouts")
+	best = map[primitive.ObjectID]map[int]float64{}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"userID":               userID,
+			"workoutDate":          bson.M{"$lt": before},
+			"exercises.exerciseID": bson.M{"$in": exerciseIDs},
+		}}},
+		{{Key: "$unwind", Value: "$exercises"}},
+		{{Key: "$match", Value: bson.M{"exercises.exerciseID": bson.M{"$in": exerciseIDs}}}},
+		{{Key: "$unwind", Value: "$exercises.sets"}},
+		{{Key: "$match", Value: bson.M{
+			"exercises.sets.reps":   bson.M{"$gte": 1, "$lte": maxReps},
+			"exercises.sets.weight": bson.M{"$gt": 0},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"exercise": "$exercises.exerciseID", "reps": "$exercises.sets.reps"},
+			"weight": bson.M{"$max": "$exercises.sets.weight"},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var row struct {
+			Key struct {
+				Exercise primitive.ObjectID `bson:"exercise"`
+				Reps     int                `bson:"reps"`
+			} `bson:"_id"`
+			Weight float64 `bson:"weight"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			log.Printf("Error decoding best weight: %v", err)
+			continue
+		}
+		if best[row.Key.Exercise] == nil {
+			best[row.Key.Exercise] = map[int]float64{}
+		}
+		best[row.Key.Exercise][row.Key.Reps] = row.Weight
+	}
+
+	err = cursor.Err()
 	return
 }
 
 // Cardio feature removed
 
-func GetLastTwoWorkouts(userID, routineID primitive.ObjectID) (workouts []models.FullWorkout, err error) {
+// GetExercisesByIDs loads the given exercises in a single query, keyed by ID.
+func GetExercisesByIDs(exerciseIDs []primitive.ObjectID) (exercises map[primitive.ObjectID]models.Exercise, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	collection := GetCollection("exercises")
+
+	exercises = make(map[primitive.ObjectID]models.Exercise, len(exerciseIDs))
+
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": exerciseIDs}})
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var exercise models.Exercise
+		if err := cursor.Decode(&exercise); err != nil {
+			continue
+		}
+		exercises[exercise.ID] = exercise
+	}
+
+	err = cursor.Err()
+	return
+}
+
+// GetUserWorkoutsInRange returns the user's full workouts dated within
+// [from, to), oldest first.
+func GetUserWorkoutsInRange(userID primitive.ObjectID, from, to time.Time) (workouts []models.FullWorkout, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
 	collection := GetCollection("workouts")
-	opts := options.Find().SetSort(bson.D{{Key: "workoutDate", Value: -1}}).SetLimit(2)
+	opts := options.Find().SetSort(bson.D{{Key: "workoutDate", Value: 1}})
 
 	cursor, err := collection.Find(ctx, bson.M{
-		"userID":    userID,
-		"routineID": routineID,
+		"userID": userID,
+		"workoutDate": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(from),
+			"$lt":  primitive.NewDateTimeFromTime(to),
+		},
 	}, opts)
 	if err != nil {
 		return
@@ -348,12 +1110,181 @@ func GetLastTwoWorkouts(userID, routineID primitive.ObjectID) (workouts []models
 
 	for cursor.Next(ctx) {
 		var workout models.FullWorkout
-		err = cursor.Decode(&workout)
-		if err != nil {
-			return
+		if err := cursor.Decode(&workout); err != nil {
+			log.Printf("Error decoding workout document: %v", err)
+			continue
 		}
 		workouts = append(workouts, workout)
 	}
 
+	err = cursor.Err()
+	return
+}
+
+// GetExerciseNames resolves exercise names in a single query. IDs that don't
+// match an exercise are absent from the map.
+func GetExerciseNames(exerciseIDs []primitive.ObjectID) (names map[primitive.ObjectID]string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exercises")
+	opts := options.Find().SetProjection(bson.M{"name": 1})
+
+	names = make(map[primitive.ObjectID]string, len(exerciseIDs))
+
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": exerciseIDs}}, opts)
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var exercise models.Exercise
+		if err := cursor.Decode(&exercise); err != nil {
+			continue
+		}
+		names[exercise.ID] = exercise.Name
+	}
+
+	err = cursor.Err()
+	return
+}
+
+func GetEquipmentInventory(userID primitive.ObjectID) (inventory models.EquipmentInventory, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("equipmentInventory")
+
+	err = collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&inventory)
+	return
+}
+
+func GetMeasurement(measurementID, userID primitive.ObjectID) (measurement models.BodyMeasurement, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("measurements")
+
+	err = collection.FindOne(ctx, bson.M{"_id": measurementID, "userID": userID}).Decode(&measurement)
+	return
+}
+
+// GetUserMeasurements returns the user's log entries in [from, to), oldest
+// first. A zero bound leaves that side open.
+func GetUserMeasurements(userID primitive.ObjectID, from, to time.Time) (measurements []models.BodyMeasurement, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := GetCollection("measurements")
+
+	filter := bson.M{"userID": userID}
+	date := bson.M{}
+	if !from.IsZero() {
+		date["$gte"] = primitive.NewDateTimeFromTime(from)
+	}
+	if !to.IsZero() {
+		date["$lt"] = primitive.NewDateTimeFromTime(to)
+	}
+	if len(date) > 0 {
+		filter["date"] = date
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	measurements = []models.BodyMeasurement{}
+	err = cursor.All(ctx, &measurements)
+	return
+}
+
+// GetBodyweightLog returns the user's logged bodyweights, oldest first,
+// leaving out entries without one.
+func GetBodyweightLog(userID primitive.ObjectID) (measurements []models.BodyMeasurement, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := GetCollection("measurements")
+	opts := options.Find().
+		SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"date": 1, "bodyweight": 1})
+
+	cursor, err := collection.Find(ctx, bson.M{"userID": userID, "bodyweight": bson.M{"$gt": 0}}, opts)
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &measurements)
+	return
+}
+
+// GetExercisesBySlugs looks up global catalog exercises by slug. Slugs that
+// don't match an exercise are absent from the map.
+func GetExercisesBySlugs(slugs []string) (exercises map[string]models.Exercise, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exercises")
+
+	exercises = make(map[string]models.Exercise, len(slugs))
+
+	cursor, err := collection.Find(ctx, bson.M{
+		"slug":    bson.M{"$in": slugs},
+		"ownerID": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var exercise models.Exercise
+		if err := cursor.Decode(&exercise); err != nil {
+			continue
+		}
+		exercises[exercise.Slug] = exercise
+	}
+
+	err = cursor.Err()
+	return
+}
+
+func GetGoal(goalID, userID primitive.ObjectID) (goal models.Goal, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("goals")
+
+	err = collection.FindOne(ctx, bson.M{"_id": goalID, "userID": userID}).Decode(&goal)
+	return
+}
+
+// GetUserGoals returns the user's goals, newest first, optionally only those
+// with the given status.
+func GetUserGoals(userID primitive.ObjectID, status string) (goals []models.Goal, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("goals")
+
+	filter := bson.M{"userID": userID}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	goals = []models.Goal{}
+	err = cursor.All(ctx, &goals)
 	return
 }
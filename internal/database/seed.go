@@ -75,6 +75,9 @@ func SeedExercisesFromJSON(ctx context.Context, db *mongo.Database, path string)
 		if it.Name == "" {
 			continue
 		}
+		if it.Slug == "" {
+			it.Slug = Slugify(it.Name)
+		}
 		if _, exists := seen[it.Name]; exists {
 			continue
 		}
@@ -100,10 +103,13 @@ func SeedExercisesFromJSON(ctx context.Context, db *mongo.Database, path string)
 func ensureWarmupCooldownDefaults(ctx context.Context, exercises *mongo.Collection) error {
 	// Warm-Up
 	if inserted, err := upsertExerciseByName(ctx, exercises, models.Exercise{
-		Name:       "Warm-Up",
-		Category:   "General",
-		Variations: []string{"None"},
-		Equipment:  []string{"None"},
+		Name:            "Warm-Up",
+		Category:        "General",
+		Variations:      []string{"None"},
+		Equipment:       []string{"None"},
+		MovementPattern: "mobility",
+		Force:           "static",
+		Mechanic:        "compound",
 	}); err != nil {
 		return err
 	} else if inserted {
@@ -111,10 +117,13 @@ func ensureWarmupCooldownDefaults(ctx context.Context, exercises *mongo.Collecti
 	}
 	// Cool-Down
 	if inserted, err := upsertExerciseByName(ctx, exercises, models.Exercise{
-		Name:       "Cool-Down",
-		Category:   "General",
-		Variations: []string{"None"},
-		Equipment:  []string{"None"},
+		Name:            "Cool-Down",
+		Category:        "General",
+		Variations:      []string{"None"},
+		Equipment:       []string{"None"},
+		MovementPattern: "mobility",
+		Force:           "static",
+		Mechanic:        "compound",
 	}); err != nil {
 		return err
 	} else if inserted {
@@ -125,7 +134,7 @@ func ensureWarmupCooldownDefaults(ctx context.Context, exercises *mongo.Collecti
 
 func upsertExerciseByName(ctx context.Context, exercises *mongo.Collection, ex models.Exercise) (bool, error) {
 	res, err := exercises.UpdateOne(ctx,
-		bson.M{"name": ex.Name},
+		bson.M{"name": ex.Name, "ownerID": bson.M{"$exists": false}},
 		bson.M{"$setOnInsert": ex},
 		options.Update().SetUpsert(true),
 	)
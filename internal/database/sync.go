@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ExerciseChange lists the fields a sync rewrote on one catalog exercise.
+type ExerciseChange struct {
+	Name   string   `json:"name"`
+	Slug   string   `json:"slug"`
+	Fields []string `json:"fields"`
+}
+
+// CatalogSyncReport describes what a sync did, or would do in a dry run.
+// Removed exercises are only reported; they may still be referenced by user
+// data, so archiving or merging them is left to an admin. Archived exercises
+// are not reported again.
+type CatalogSyncReport struct {
+	DryRun    bool             `json:"dry_run"`
+	Inserted  []string         `json:"inserted"`
+	Updated   []ExerciseChange `json:"updated"`
+	Removed   []string         `json:"removed"`
+	Unchanged int              `json:"unchanged"`
+}
+
+// Slugify turns an exercise name into its catalog slug, e.g. "Pull-Up (Wide)"
+// becomes "pull-up-wide".
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// SyncExercisesFromJSON reconciles the global catalog with the JSON file.
+// Entries are matched by slug, falling back to a case-insensitive name match
+// for documents seeded before slugs existed. New entries are inserted and
+// changed catalog fields are overwritten; user-owned exercises, uploaded media
+// and the archived flag are never touched. With dryRun nothing is written.
+func SyncExercisesFromJSON(ctx context.Context, db *mongo.Database, path string, dryRun bool) (*CatalogSyncReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.Exercise
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	collection := db.Collection("exercises")
+
+	findCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(findCtx, ownerScope(primitive.NilObjectID))
+	if err != nil {
+		return nil, err
+	}
+	var existing []models.Exercise
+	if err := cursor.All(findCtx, &existing); err != nil {
+		return nil, err
+	}
+
+	bySlug := map[string]int{}
+	byName := map[string]int{}
+	for i, ex := range existing {
+		if ex.Slug != "" {
+			bySlug[ex.Slug] = i
+		}
+		byName[strings.ToLower(ex.Name)] = i
+	}
+
+	report := &CatalogSyncReport{
+		DryRun:   dryRun,
+		Inserted: []string{},
+		Updated:  []ExerciseChange{},
+		Removed:  []string{},
+	}
+	matched := make([]bool, len(existing))
+	seen := map[string]bool{}
+
+	writeCtx, cancelWrite := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelWrite()
+
+	for _, item := range items {
+		if item.Name == "" {
+			continue
+		}
+		if item.Slug == "" {
+			item.Slug = Slugify(item.Name)
+		}
+		if seen[item.Slug] {
+			continue
+		}
+		seen[item.Slug] = true
+		item.ID = primitive.NilObjectID
+		item.OwnerID = primitive.NilObjectID
+		item.Archived = false
+		item.Media = nil
+
+		i, ok := bySlug[item.Slug]
+		if !ok {
+			i, ok = byName[strings.ToLower(item.Name)]
+			if ok && existing[i].Slug != "" && existing[i].Slug != item.Slug {
+				// The name now belongs to a different catalog entry
+				ok = false
+			}
+		}
+		if !ok || matched[i] {
+			report.Inserted = append(report.Inserted, item.Name)
+			if !dryRun {
+				if _, err := collection.InsertOne(writeCtx, item); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		matched[i] = true
+
+		updates := catalogUpdates(existing[i], item)
+		if len(updates) == 0 {
+			report.Unchanged++
+			continue
+		}
+
+		change := ExerciseChange{Name: item.Name, Slug: item.Slug}
+		for field := range updates {
+			change.Fields = append(change.Fields, field)
+		}
+		slices.Sort(change.Fields)
+		report.Updated = append(report.Updated, change)
+
+		if !dryRun {
+			_, err := collection.UpdateOne(writeCtx, bson.M{"_id": existing[i].ID}, bson.M{"$set": updates})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, ex := range existing {
+		if !matched[i] && !ex.Archived {
+			report.Removed = append(report.Removed, ex.Name)
+		}
+	}
+
+	return report, nil
+}
+
+// catalogUpdates returns the catalog-managed fields of want that differ from
+// have, keyed by their BSON names.
+func catalogUpdates(have, want models.Exercise) bson.M {
+	updates := bson.M{}
+	setString := func(field, a, b string) {
+		if a != b {
+			updates[field] = b
+		}
+	}
+	setStrings := func(field string, a, b []string) {
+		if !slices.Equal(a, b) {
+			if b == nil {
+				b = []string{}
+			}
+			updates[field] = b
+		}
+	}
+
+	setString("name", have.Name, want.Name)
+	setString("slug", have.Slug, want.Slug)
+	setString("category", have.Category, want.Category)
+	setStrings("variations", have.Variations, want.Variations)
+	setStrings("equipment", have.Equipment, want.Equipment)
+	setStrings("aliases", have.Aliases, want.Aliases)
+	setStrings("primaryMuscles", have.PrimaryMuscles, want.PrimaryMuscles)
+	setStrings("secondaryMuscles", have.SecondaryMuscles, want.SecondaryMuscles)
+	setString("movementPattern", have.MovementPattern, want.MovementPattern)
+	setString("force", have.Force, want.Force)
+	setString("mechanic", have.Mechanic, want.Mechanic)
+	setStrings("instructions", have.Instructions, want.Instructions)
+	setStrings("cues", have.Cues, want.Cues)
+	setStrings("commonMistakes", have.CommonMistakes, want.CommonMistakes)
+	if len(have.Translations) > 0 || len(want.Translations) > 0 {
+		if !reflect.DeepEqual(have.Translations, want.Translations) {
+			updates["translations"] = want.Translations
+		}
+	}
+
+	return updates
+}
@@ -24,6 +24,10 @@ func UpsertSession(session models.WorkoutSession) (sessionID primitive.ObjectID,
 	// Ensure TTL is current
 	session.LastUpdate = primitive.NewDateTimeFromTime(time.Now())
 	update := bson.M{"$set": session}
+	if session.PausedAt == 0 {
+		// Replacing a paused session must not inherit its pause timestamp
+		update["$unset"] = bson.M{"pausedAt": ""}
+	}
 	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
 
 	var result models.WorkoutSession
@@ -90,6 +94,116 @@ func UpdateExercise(exerciseID primitive.ObjectID, updates bson.M) (err error) {
 	return
 }
 
+// PromoteExercise moves a user's custom exercise into the global catalog by
+// clearing its owner. Fails with a duplicate key error if a global exercise
+// already has the same name.
+func PromoteExercise(exerciseID primitive.ObjectID) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exercises")
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{
+			"_id":     exerciseID,
+			"ownerID": bson.M{"$exists": true},
+		},
+		bson.M{
+			"$unset": bson.M{"ownerID": ""},
+		},
+	)
+	if err != nil {
+		log.Println("Error promoting exercise")
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return
+}
+
+// AddExerciseMedia appends a media reference to an exercise.
+func AddExerciseMedia(exerciseID primitive.ObjectID, media models.ExerciseMedia) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exercises")
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": exerciseID},
+		bson.M{"$push": bson.M{"media": media}},
+	)
+	if err != nil {
+		log.Println("Error adding exercise media")
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return
+}
+
+// RemoveExerciseMedia drops a media reference from an exercise. The blob
+// itself is deleted by the caller.
+func RemoveExerciseMedia(exerciseID, mediaID primitive.ObjectID) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("exercises")
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": exerciseID},
+		bson.M{"$pull": bson.M{"media": bson.M{"_id": mediaID}}},
+	)
+	if err != nil {
+		log.Println("Error removing exercise media")
+		return
+	}
+
+	if result.ModifiedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return
+}
+
+// ReplaceExerciseReferences points every embedded exercise entry in the given
+// collection (routines, workouts or sessions) at targetID instead of sourceID,
+// updating the denormalized name too. Returns the number of documents changed.
+func ReplaceExerciseReferences(collectionName string, sourceID, targetID primitive.ObjectID, targetName string) (modified int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	collection := GetCollection(collectionName)
+
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"e.exerciseID": sourceID}},
+	})
+
+	result, err := collection.UpdateMany(ctx,
+		bson.M{
+			"exercises.exerciseID": sourceID,
+		},
+		bson.M{
+			"$set": bson.M{
+				"exercises.$[e].exerciseID": targetID,
+				"exercises.$[e].name":       targetName,
+			},
+		},
+		opts,
+	)
+	if err != nil {
+		log.Printf("Error replacing exercise references in %s: %v", collectionName, err)
+		return
+	}
+
+	return result.ModifiedCount, nil
+}
+
 func UpdateRoutine(routineID, userID primitive.ObjectID, updates bson.M) (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -165,3 +279,74 @@ func UpdateExerciseHistory(exerciseID, userID primitive.ObjectID, updates bson.M
 
 	return
 }
+
+// UpsertEquipmentInventory replaces the user's equipment inventory, creating it
+// on first save.
+func UpsertEquipmentInventory(inventory models.EquipmentInventory) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("equipmentInventory")
+
+	inventory.ID = primitive.NilObjectID
+	inventory.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	opts := options.Replace().SetUpsert(true)
+	_, err = collection.ReplaceOne(ctx, bson.M{"userID": inventory.UserID}, inventory, opts)
+	if err != nil {
+		log.Println("Error saving equipment inventory", err)
+	}
+	return
+}
+
+// ReplaceMeasurement overwrites one of the user's log entries.
+func ReplaceMeasurement(measurement models.BodyMeasurement) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("measurements")
+
+	result, err := collection.ReplaceOne(ctx, bson.M{"_id": measurement.ID, "userID": measurement.UserID}, measurement)
+	if err != nil {
+		log.Println("Error updating measurement", err)
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return
+}
+
+// ReplaceGoalExercise points every goal on sourceID at targetID.
+func ReplaceGoalExercise(sourceID, targetID primitive.ObjectID) (modified int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := GetCollection("goals")
+
+	result, err := collection.UpdateMany(ctx,
+		bson.M{"exerciseID": sourceID},
+		bson.M{"$set": bson.M{"exerciseID": targetID}},
+	)
+	if err != nil {
+		log.Println("Error replacing goal exercise", err)
+		return
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func UpdateGoal(goalID primitive.ObjectID, updates bson.M) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := GetCollection("goals")
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": goalID}, bson.M{"$set": updates})
+	if err != nil {
+		log.Println("Error updating goal", err)
+	}
+	return
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 
 	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/middleware"
+	"fitness-tracker/internal/service"
 	"fitness-tracker/internal/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -58,3 +61,159 @@ func DeleteSessionHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// DeleteExerciseHandler hard-deletes an exercise only when nothing references
+// it; otherwise it responds 409 with the reference report so the caller can
+// archive or merge instead.
+func DeleteExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	exerciseID := r.URL.Query().Get("exercise_id")
+	if exerciseID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing exercise_id")
+		return
+	}
+
+	exerciseObjID, err := primitive.ObjectIDFromHex(exerciseID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
+		return
+	}
+
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	exercise, err := database.GetExerciseData(exerciseObjID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Exercise not found")
+		return
+	}
+
+	if !service.CanEditExercise(userObjID, exercise) {
+		utils.ErrorResponse(w, http.StatusForbidden, "Not allowed to modify this exercise")
+		return
+	}
+
+	report, err := service.DeleteUnreferencedExercise(exercise)
+	if err != nil {
+		if err == service.ErrExerciseInUse {
+			utils.JSONResponse(w, http.StatusConflict, report)
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't delete exercise")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteExerciseMediaHandler removes a demo image or video from an exercise.
+func DeleteExerciseMediaHandler(w http.ResponseWriter, r *http.Request) {
+	exerciseID := r.URL.Query().Get("exercise_id")
+	if exerciseID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing exercise_id")
+		return
+	}
+
+	exerciseObjID, err := primitive.ObjectIDFromHex(exerciseID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
+		return
+	}
+
+	mediaObjID, err := primitive.ObjectIDFromHex(r.URL.Query().Get("media_id"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing or invalid media_id")
+		return
+	}
+
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	exercise, err := database.GetExerciseData(exerciseObjID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Exercise not found")
+		return
+	}
+
+	if !service.CanEditExercise(userObjID, exercise) {
+		utils.ErrorResponse(w, http.StatusForbidden, "Not allowed to modify this exercise")
+		return
+	}
+
+	if err := service.DeleteExerciseMedia(exercise, mediaObjID); err != nil {
+		if err == service.ErrMediaNotFound {
+			utils.ErrorResponse(w, http.StatusNotFound, "Media not found")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't delete media")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func DeleteMeasurementHandler(w http.ResponseWriter, r *http.Request) {
+	measurementID := r.URL.Query().Get("measurement_id")
+	if measurementID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing measurement_id")
+		return
+	}
+
+	measurementObjID, err := primitive.ObjectIDFromHex(measurementID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	if err := database.DeleteMeasurement(measurementObjID, userObjID); err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Measurement not found")
+		return
+	}
+
+	if err := service.SyncProfileBodyweight(userObjID); err != nil {
+		log.Println("Failed to sync profile bodyweight", err)
+	}
+	if err := service.EvaluateGoals(userObjID); err != nil {
+		log.Println("Failed to evaluate goals", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func DeleteGoalHandler(w http.ResponseWriter, r *http.Request) {
+	goalID := r.URL.Query().Get("goal_id")
+	if goalID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing goal_id")
+		return
+	}
+
+	goalObjID, err := primitive.ObjectIDFromHex(goalID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	if err := database.DeleteGoal(goalObjID, userObjID); err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Goal not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
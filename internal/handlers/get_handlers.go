@@ -2,10 +2,19 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"fitness-tracker/internal/analytics"
 	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/middleware"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/service"
+	"fitness-tracker/internal/units"
 	"fitness-tracker/internal/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -29,6 +38,8 @@ func GetUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	service.UserForDisplay(&user)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
@@ -44,6 +55,10 @@ func GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i := range userList {
+		service.UserForDisplay(&userList[i])
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(userList)
 }
@@ -71,6 +86,8 @@ func GetUserByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	service.UserForDisplay(&user)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
@@ -84,10 +101,21 @@ func GetExerciseIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Optional: resolve the user's custom exercises first
+	var userObjID primitive.ObjectID
+	if userID := r.URL.Query().Get("user_id"); userID != "" {
+		var err error
+		userObjID, err = primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+			return
+		}
+	}
+
 	exerciseIDs := make([]string, len(exerciseNames))
 
 	for i, v := range exerciseNames {
-		exercise_id, err := database.GetExerciseID(v)
+		exercise_id, err := database.GetExerciseIDForUser(v, userObjID)
 		if err == nil {
 			exerciseIDs[i] = exercise_id
 		} else {
@@ -118,10 +146,11 @@ func GetExerciseNameHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	locales := requestLocales(r)
 	for i, v := range exerciseObjIDs {
-		exerciseName, err := database.GetExerciseName(v)
+		exercise, err := database.GetExerciseData(v)
 		if err == nil {
-			exerciseNames[i] = exerciseName
+			exerciseNames[i] = service.LocalizeExercise(exercise, locales).Name
 		} else {
 			exerciseNames[i] = "unknown"
 		}
@@ -131,11 +160,211 @@ func GetExerciseNameHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(exerciseNames)
 }
 
+// GetExerciseListHandler searches the catalog and returns the page of
+// exercises as a bare array, the shape the endpoint has always returned. The
+// number of matches is sent in the X-Total-Count header.
 func GetExerciseListHandler(w http.ResponseWriter, r *http.Request) {
-	exerciseList := database.GetExerciseList()
+	result, ok := searchCatalog(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(result.Total))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result.Exercises)
+}
+
+// GetExerciseSearchHandler serves /v2/exercise/list: the page of exercises
+// with the total and facet counts.
+func GetExerciseSearchHandler(w http.ResponseWriter, r *http.Request) {
+	result, ok := searchCatalog(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// searchCatalog reads a catalog search request and runs it. It writes the
+// error response and returns false on failure.
+func searchCatalog(w http.ResponseWriter, r *http.Request) (result *service.ExerciseSearchResult, ok bool) {
+	query := r.URL.Query()
+
+	search := service.ExerciseSearch{
+		Query: strings.TrimSpace(query.Get("q")),
+		Filter: database.ExerciseFilter{
+			Category:        query.Get("category"),
+			Equipment:       query["equipment"],
+			Variation:       query.Get("variation"),
+			Muscle:          query.Get("muscle"),
+			PrimaryMuscle:   query.Get("primary_muscle"),
+			MovementPattern: query.Get("movement_pattern"),
+			Force:           query.Get("force"),
+			Mechanic:        query.Get("mechanic"),
+			IncludeArchived: query.Get("include_archived") == "true",
+		},
+		Sort:    query.Get("sort"),
+		Locales: requestLocales(r),
+	}
+
+	switch search.Sort {
+	case "", service.SortRelevance, service.SortName, service.SortNameDesc, service.SortCategory:
+	default:
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid sort")
+		return
+	}
+
+	// Optional: merge the user's custom exercises into the catalog
+	if userID := query.Get("user_id"); userID != "" {
+		ownerID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+			return
+		}
+		search.Filter.OwnerID = ownerID
+	}
+
+	limit, err := utils.ParseLimitParam(query.Get("limit"), 50, 200)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+	search.Limit = limit
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		search.Offset, err = strconv.Atoi(offsetStr)
+		if err != nil || search.Offset < 0 {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid offset")
+			return
+		}
+	}
+
+	result, err = service.SearchCatalog(search)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't search exercises")
+		return
+	}
+	return result, true
+}
+
+// GetExerciseReferencesHandler reports how many routines, workouts, sessions
+// and users depend on an exercise.
+func GetExerciseReferencesHandler(w http.ResponseWriter, r *http.Request) {
+	exerciseID := r.URL.Query().Get("exercise_id")
+	if exerciseID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing exercise_id")
+		return
+	}
+
+	exerciseObjID, err := primitive.ObjectIDFromHex(exerciseID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
+		return
+	}
+
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	exercise, err := database.GetExerciseData(exerciseObjID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Exercise not found")
+		return
+	}
+
+	if !service.CanEditExercise(userObjID, exercise) {
+		utils.ErrorResponse(w, http.StatusForbidden, "Not allowed to modify this exercise")
+		return
+	}
+
+	report, err := service.ExerciseReferenceReportFor(exercise)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't count exercise references")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetExerciseMediaHandler streams one of an exercise's demo images or videos.
+// Media of private exercises is only served to their owner and admins.
+func GetExerciseMediaHandler(w http.ResponseWriter, r *http.Request) {
+	exerciseID := r.URL.Query().Get("exercise_id")
+	if exerciseID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing exercise_id")
+		return
+	}
+
+	exerciseObjID, err := primitive.ObjectIDFromHex(exerciseID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
+		return
+	}
+
+	mediaObjID, err := primitive.ObjectIDFromHex(r.URL.Query().Get("media_id"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing or invalid media_id")
+		return
+	}
+
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	exercise, err := database.GetExerciseData(exerciseObjID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Exercise not found")
+		return
+	}
+
+	if !service.CanViewExercise(userObjID, exercise) {
+		utils.ErrorResponse(w, http.StatusForbidden, "Not allowed to view this exercise")
+		return
+	}
+
+	blob, info, err := service.OpenExerciseMedia(exercise, mediaObjID)
+	if err != nil {
+		if err == service.ErrMediaNotFound {
+			utils.ErrorResponse(w, http.StatusNotFound, "Media not found")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't read media")
+		}
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	// Media of private exercises must not be kept by shared caches
+	if exercise.OwnerID.IsZero() {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+	} else {
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+	}
+	io.Copy(w, blob)
+}
+
+// GetMediaTypesHandler lists the content types accepted for media uploads.
+func GetMediaTypesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service.AllowedMediaTypes())
+}
+
+func GetDuplicateExercisesHandler(w http.ResponseWriter, r *http.Request) {
+	groups, err := service.FindDuplicateExercises()
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't look for duplicates")
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(exerciseList)
+	json.NewEncoder(w).Encode(groups)
 }
 
 func GetExerciseDataHandler(w http.ResponseWriter, r *http.Request) {
@@ -157,10 +386,31 @@ func GetExerciseDataHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// translations=true returns every translation instead of localizing
+	if r.URL.Query().Get("translations") != "true" {
+		exercise = service.LocalizeExercise(exercise, requestLocales(r))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(exercise)
 }
 
+// requestLocales picks the catalog languages for a request from the caller's
+// saved locale (when a user ID is sent) and the Accept-Language header.
+func requestLocales(r *http.Request) []string {
+	var userLocale string
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		userID = r.URL.Query().Get("user_id")
+	}
+	if userObjID, err := primitive.ObjectIDFromHex(userID); err == nil {
+		if user, err := database.GetUserByID(userObjID); err == nil {
+			userLocale = user.Locale
+		}
+	}
+	return service.PreferredLocales(userLocale, r.Header.Get("Accept-Language"))
+}
+
 func GetRoutineListHandler(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
@@ -219,6 +469,9 @@ func GetRoutineDataHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(routine)
 }
 
+// GetWorkoutListHandler lists every one of the user's workouts, newest
+// first, as a bare array of workouts. routine_id narrows the list to one
+// routine. Paging, filters and summaries are served by /v2/workouts/list.
 func GetWorkoutListHandler(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	routineID := r.URL.Query().Get("routine_id")
@@ -234,22 +487,126 @@ func GetWorkoutListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	routineObjID, err := primitive.ObjectIDFromHex(routineID)
-	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid routine_id")
-		return
+	var routineObjID primitive.ObjectID
+	if routineID != "" {
+		routineObjID, err = primitive.ObjectIDFromHex(routineID)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid routine_id")
+			return
+		}
 	}
 
-	workoutList, err := database.GetUserWorkoutsByRoutine(userObjID, routineObjID)
+	workoutList, err := database.GetUserWorkouts(userObjID, routineObjID)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusNotFound, "No workouts found for routine")
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't fetch workouts")
 		return
 	}
+	if workoutList == nil {
+		workoutList = []models.Workout{}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(workoutList)
 }
 
+// GetWorkoutPageHandler serves /v2/workouts/list: a page of workout
+// summaries, filtered and sorted newest first, with the cursor of the next
+// page and the unit.
+func GetWorkoutPageHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := workoutPage(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// workoutPage reads a workout list request and loads the page, converted for
+// display. It writes the error response and returns false on failure.
+func workoutPage(w http.ResponseWriter, r *http.Request) (page models.WorkoutSummaryPage, ok bool) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	filter := database.WorkoutListFilter{
+		Tags:  service.NormalizeTags(query["tag"]),
+		Query: strings.TrimSpace(query.Get("q")),
+	}
+
+	if routineID := query.Get("routine_id"); routineID != "" {
+		filter.RoutineID, err = primitive.ObjectIDFromHex(routineID)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid routine_id")
+			return
+		}
+	}
+
+	if exerciseID := query.Get("exercise_id"); exerciseID != "" {
+		filter.ExerciseID, err = primitive.ObjectIDFromHex(exerciseID)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
+			return
+		}
+	}
+
+	prefs := service.LoadUserPrefs(userObjID)
+	loc := prefs.Location
+	filter.From, err = utils.ParseDateParamIn(query.Get("from"), false, loc)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid from date")
+		return
+	}
+
+	filter.To, err = utils.ParseDateParamIn(query.Get("to"), true, loc)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid to date")
+		return
+	}
+
+	limit, err := utils.ParseLimitParam(query.Get("limit"), 20, 100)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+
+	var after *database.WorkoutCursor
+	if token := query.Get("cursor"); token != "" {
+		cursor, err := database.DecodeWorkoutCursor(token)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+		after = &cursor
+	}
+
+	workoutList, next, err := database.ListUserWorkouts(userObjID, filter, after, limit)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't fetch workouts")
+		return
+	}
+
+	page = models.WorkoutSummaryPage{Workouts: workoutList}
+	if page.Workouts == nil {
+		page.Workouts = []models.WorkoutSummary{}
+	}
+	if next != nil {
+		page.NextCursor = next.Encode()
+	}
+	service.WorkoutPageForDisplay(&page, prefs.Unit)
+	return page, true
+}
+
 func GetWorkoutDataHandler(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	workoutID := r.URL.Query().Get("workout_id")
@@ -281,6 +638,8 @@ func GetWorkoutDataHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	service.WorkoutForDisplay(&workout, service.UserWeightUnit(userObjID))
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(workout)
 }
@@ -318,10 +677,62 @@ func GetSessionHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	service.SessionForDisplay(&session, service.UserWeightUnit(userObjID))
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(session)
 }
 
+func GetSessionSubstitutesHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("session_id")
+	exerciseIndexStr := r.URL.Query().Get("exercise_index")
+
+	if sessionID == "" || exerciseIndexStr == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing session_id or exercise_index")
+		return
+	}
+
+	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid session_id format")
+		return
+	}
+
+	exIndex, err := strconv.Atoi(exerciseIndexStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_index")
+		return
+	}
+
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	substitutes, err := service.SuggestSubstitutes(sessionObjID, exIndex, r.URL.Query()["equipment"], limit)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.ErrorResponse(w, http.StatusNotFound, "Session or exercise not found")
+		} else if err == service.ErrExerciseIndex {
+			utils.ErrorResponse(w, http.StatusBadRequest, "exercise_index out of range")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't suggest substitutes")
+		}
+		return
+	}
+
+	if substitutes == nil {
+		substitutes = []service.Substitute{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(substitutes)
+}
+
 func CountWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 
@@ -374,26 +785,48 @@ func GetExerciseHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type ProcessedHistory struct {
-		Date   string  `json:"date"`
-		Weight float64 `json:"weight,omitempty"`
-		Volume float64 `json:"volume,omitempty"`
+		Date             string  `json:"date"`
+		Weight           float64 `json:"weight,omitempty"`
+		Volume           float64 `json:"volume,omitempty"`
+		RelativeStrength float64 `json:"relative_strength,omitempty"`
 	}
 
-	// Group by date and calculate max weight and volume
+	// Bodyweight exercises count the bodyweight logged for the day as load.
+	// History can outlive its exercise, which then counts no bodyweight.
+	exercise, err := database.GetExerciseData(exerciseObjID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve data")
+		return
+	}
+	exerciseFound := err == nil
+	bodyweights := service.LoadBodyweightLog(userObjID)
+
+	// Group by date in the user's time zone and calculate max weight and volume
+	prefs := service.LoadUserPrefs(userObjID)
+	loc := prefs.Location
 	dailyMap := make(map[string]struct {
-		MaxW   float64
-		Volume float64
+		MaxW       float64
+		Volume     float64
+		Load       float64
+		Bodyweight float64
 	})
 
 	for _, day := range exerciseHistory.Sets {
-		dateStr := day.Date.Time().Format("2006-01-02")
+		dateStr := day.Date.Time().In(loc).Format("2006-01-02")
+		bodyweight := bodyweights.At(day.Date.Time())
+		load := 0.0
+		if exerciseFound && service.IsBodyweightExercise(exercise, day.Equipment) {
+			load = bodyweight
+		}
 		for _, s := range day.WorkoutSets {
-			if s.Reps > 0 && s.Weight > 0 {
+			if s.Reps > 0 && s.Weight+load > 0 {
 				entry := dailyMap[dateStr]
 				if s.Weight > entry.MaxW {
 					entry.MaxW = s.Weight
 				}
-				entry.Volume += s.Weight * float64(s.Reps)
+				entry.Volume += (s.Weight + load) * float64(s.Reps)
+				entry.Load = load
+				entry.Bodyweight = bodyweight
 				dailyMap[dateStr] = entry
 			}
 		}
@@ -412,13 +845,15 @@ func GetExerciseHistoryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	unit := prefs.Unit
 	results := make([]ProcessedHistory, 0, len(sortedDates))
 	for _, date := range sortedDates {
 		entry := dailyMap[date]
 		results = append(results, ProcessedHistory{
-			Date:   date,
-			Weight: entry.MaxW,
-			Volume: entry.Volume,
+			Date:             date,
+			Weight:           service.DisplayWeight(entry.MaxW, unit),
+			Volume:           service.DisplayAmount(entry.Volume, unit),
+			RelativeStrength: service.RelativeStrength(entry.MaxW+entry.Load, entry.Bodyweight),
 		})
 	}
 
@@ -426,113 +861,705 @@ func GetExerciseHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(results)
 }
 
+// GetWorkoutComparisonHandler compares workouts and returns the per-exercise
+// comparisons as a bare array, the shape the endpoint has always returned.
 func GetWorkoutComparisonHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	routineID := r.URL.Query().Get("routine_id")
-
-	if userID == "" || routineID == "" {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id or routine_id")
+	comparison, ok := workoutComparison(w, r)
+	if !ok {
 		return
 	}
 
-	userObjID, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
-		return
-	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison.Exercises)
+}
 
-	routineObjID, err := primitive.ObjectIDFromHex(routineID)
-	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid routine_id")
+// GetWorkoutComparisonV2Handler serves /v2/workouts/comparison: the
+// comparison with the compared workouts and unit.
+func GetWorkoutComparisonV2Handler(w http.ResponseWriter, r *http.Request) {
+	comparison, ok := workoutComparison(w, r)
+	if !ok {
 		return
 	}
 
-	workouts, err := database.GetLastTwoWorkouts(userObjID, routineObjID)
-	if err != nil {
-		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch workouts")
-		return
-	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison)
+}
 
-	if len(workouts) < 2 {
-		utils.ErrorResponse(w, http.StatusNotFound, "Not enough workouts to compare")
+// workoutComparison reads a comparison request, either two workout_id
+// parameters or a routine_id with an optional number of sessions, and builds
+// the comparison, converted for display. It writes the error response and
+// returns false on failure.
+func workoutComparison(w http.ResponseWriter, r *http.Request) (comparison *service.WorkoutComparison, ok bool) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	routineID := query.Get("routine_id")
+	workoutIDs := query["workout_id"]
+
+	if userID == "" || (routineID == "" && len(workoutIDs) == 0) {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id, or routine_id / workout_id")
 		return
 	}
 
-	workout1 := workouts[0] // Latest workout
-	workout2 := workouts[1] // Second latest workout
-
-	type MetricChange struct {
-		ExerciseName string  `json:"exercise_name"`
-		Variation    string  `json:"variation"`
-		MaxWeight    float64 `json:"max_weight"`
-		TotalReps    int     `json:"reps"`
-		TotalVolume  float64 `json:"volume"`
-		WeightChange float64 `json:"weight_change"`
-		RepsChange   int     `json:"reps_change"`
-		VolumeChange float64 `json:"volume_change"`
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
 	}
 
-	exerciseMetricMap := make(map[string]MetricChange)
-
-	for _, exercise := range workout1.Exercises {
-		exercise_name, _ := database.GetExerciseName(exercise.ExerciseID)
-		key := exercise_name + "|" + exercise.Variation
+	if len(workoutIDs) > 0 {
+		if len(workoutIDs) != 2 {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Exactly two workout_id parameters are required")
+			return
+		}
 
-		max_weight, total_reps, total_volume := 0.0, 0, 0.0
+		workoutObjIDs := make([]primitive.ObjectID, len(workoutIDs))
+		for i, v := range workoutIDs {
+			workoutObjIDs[i], err = primitive.ObjectIDFromHex(v)
+			if err != nil {
+				utils.ErrorResponse(w, http.StatusBadRequest, "Invalid workout_id")
+				return
+			}
+		}
 
-		for _, set := range exercise.Sets {
-			total_reps += set.Reps
-			total_volume += float64(set.Reps) * set.Weight
+		comparison, err = service.CompareWorkouts(userObjID, workoutObjIDs)
+	} else {
+		var routineObjID primitive.ObjectID
+		routineObjID, err = primitive.ObjectIDFromHex(routineID)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid routine_id")
+			return
+		}
 
-			if set.Weight > max_weight {
-				max_weight = set.Weight
+		sessions := 2
+		if sessionsStr := query.Get("sessions"); sessionsStr != "" {
+			sessions, err = strconv.Atoi(sessionsStr)
+			if err != nil || sessions < 2 || sessions > 20 {
+				utils.ErrorResponse(w, http.StatusBadRequest, "sessions must be between 2 and 20")
+				return
 			}
 		}
 
-		exerciseMetricMap[key] = MetricChange{
-			ExerciseName: exercise_name,
-			Variation:    exercise.Variation,
-			MaxWeight:    max_weight,
-			TotalReps:    total_reps,
-			TotalVolume:  total_volume,
-			WeightChange: max_weight,
-			RepsChange:   total_reps,
-			VolumeChange: total_volume,
-		}
+		comparison, err = service.CompareRecentWorkouts(userObjID, routineObjID, sessions)
 	}
 
-	for _, exercise := range workout2.Exercises {
-		exercise_name, _ := database.GetExerciseName(exercise.ExerciseID)
-		key := exercise_name + "|" + exercise.Variation
-
-		max_weight, total_reps, total_volume := 0.0, 0, 0.0
+	if err != nil {
+		if err == service.ErrNotEnoughWorkouts {
+			utils.ErrorResponse(w, http.StatusNotFound, "Not enough workouts to compare")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch workouts")
+		}
+		return
+	}
 
-		for _, set := range exercise.Sets {
-			total_reps += set.Reps
-			total_volume += float64(set.Reps) * set.Weight
+	service.ComparisonForDisplay(comparison, service.UserWeightUnit(userObjID))
+	return comparison, true
+}
 
-			if set.Weight > max_weight {
-				max_weight = set.Weight
-			}
+func GetMuscleVolumeHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	weeks, err := utils.ParseLimitParam(query.Get("weeks"), 4, 52)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid weeks")
+		return
+	}
+
+	prefs := service.LoadUserPrefs(userObjID)
+	to := prefs.Now()
+	from := service.WeekStart(to).AddDate(0, 0, -7*(weeks-1))
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err = utils.ParseDateParamIn(fromStr, false, to.Location())
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid from date")
+			return
+		}
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, err = utils.ParseDateParamIn(toStr, true, to.Location())
+		if err != nil || !to.After(from) {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid to date")
+			return
 		}
+	}
+
+	// An explicit range applies to every muscle group, overrides included
+	target := service.DefaultVolumeTarget()
+	if v := query.Get("min_sets"); v != "" {
+		target.MinSets, err = strconv.ParseFloat(v, 64)
+		if err != nil || target.MinSets < 0 {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid min_sets")
+			return
+		}
+		target.Muscles = nil
+	}
+	if v := query.Get("max_sets"); v != "" {
+		target.MaxSets, err = strconv.ParseFloat(v, 64)
+		if err != nil || target.MaxSets < 0 {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid max_sets")
+			return
+		}
+		target.Muscles = nil
+	}
+	if target.MinSets > target.MaxSets {
+		utils.ErrorResponse(w, http.StatusBadRequest, "min_sets must not exceed max_sets")
+		return
+	}
+
+	report, err := service.WeeklyMuscleVolume(userObjID, from, to, target)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't compute muscle volume")
+		return
+	}
+
+	service.MuscleVolumeForDisplay(report, prefs.Unit)
 
-		last_workout_metrics, ok := exerciseMetricMap[key]
-		if !ok {
-			continue
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func GetEquipmentHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	inventory, err := database.GetEquipmentInventory(userObjID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.ErrorResponse(w, http.StatusNotFound, "No equipment inventory for this user")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't load equipment inventory")
 		}
+		return
+	}
 
-		last_workout_metrics.RepsChange = last_workout_metrics.TotalReps - total_reps
-		last_workout_metrics.WeightChange = last_workout_metrics.MaxWeight - max_weight
-		last_workout_metrics.VolumeChange = last_workout_metrics.TotalVolume - total_volume
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inventory)
+}
 
-		exerciseMetricMap[key] = last_workout_metrics
+// GetPlateLoadingHandler returns the plates to load per side for a target
+// weight given in the user's preferred unit. The loading is expressed in the
+// unit of the user's inventory, or of a standard gym when they have none.
+func GetPlateLoadingHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	weightStr := r.URL.Query().Get("weight")
+
+	if userID == "" || weightStr == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id or weight")
+		return
 	}
 
-	changes := make([]MetricChange, 0, len(exerciseMetricMap))
-	for _, metrics := range exerciseMetricMap {
-		changes = append(changes, metrics)
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	weight, err := strconv.ParseFloat(weightStr, 64)
+	if err != nil || weight < 0 {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid weight")
+		return
+	}
+
+	unit := service.UserWeightUnit(userObjID)
+	inventory, ok := service.UserInventory(userObjID)
+	if !ok {
+		defaults := service.DefaultInventory(userObjID, unit)
+		inventory = &defaults
+	}
+
+	target := units.FromKg(units.ToKg(weight, unit), inventory.Unit)
+	loading, err := service.LoadBar(*inventory, r.URL.Query().Get("bar"), target)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Unknown bar")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(changes)
+	json.NewEncoder(w).Encode(loading)
+}
+
+func GetMeasurementListHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	prefs := service.LoadUserPrefs(userObjID)
+	loc := prefs.Location
+	from, err := utils.ParseDateParamIn(query.Get("from"), false, loc)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid from date")
+		return
+	}
+
+	to, err := utils.ParseDateParamIn(query.Get("to"), true, loc)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid to date")
+		return
+	}
+
+	measurements, err := database.GetUserMeasurements(userObjID, from, to)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't fetch measurements")
+		return
+	}
+
+	unit := prefs.Unit
+	for i := range measurements {
+		service.MeasurementForDisplay(&measurements[i], unit)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(measurements)
+}
+
+// GetBodyTrendHandler returns bodyweight and body fat with moving averages
+// over window days (default 7) and their weekly rate of change, for the last
+// 90 days unless from/to are given.
+func GetBodyTrendHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	window, err := utils.ParseLimitParam(query.Get("window"), service.DefaultTrendWindow, 90)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid window")
+		return
+	}
+
+	prefs := service.LoadUserPrefs(userObjID)
+	to := prefs.Now()
+	from := to.AddDate(0, 0, -90)
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err = utils.ParseDateParamIn(fromStr, false, to.Location())
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid from date")
+			return
+		}
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, err = utils.ParseDateParamIn(toStr, true, to.Location())
+		if err != nil || !to.After(from) {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid to date")
+			return
+		}
+	}
+
+	trend, err := service.BodyTrendFor(userObjID, from, to, window)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't compute body trend")
+		return
+	}
+
+	service.BodyTrendForDisplay(trend, prefs.Unit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trend)
+}
+
+// GetStrengthScoresHandler reports Wilks, DOTS and IPF GL scores and strength
+// standards from the user's squat, bench and deadlift. basis is e1rm
+// (default) or actual; bodyweight, in the user's unit, overrides the logged
+// one; months sets the length of the monthly history (default 12).
+func GetStrengthScoresHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	basis := query.Get("basis")
+	if basis == "" {
+		basis = service.BasisE1RM
+	}
+	if basis != service.BasisE1RM && basis != service.BasisActual {
+		utils.ErrorResponse(w, http.StatusBadRequest, "basis must be e1rm or actual")
+		return
+	}
+
+	months, err := utils.ParseLimitParam(query.Get("months"), 12, 60)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid months")
+		return
+	}
+
+	unit := service.UserWeightUnit(userObjID)
+	bodyweight := 0.0
+	if v := query.Get("bodyweight"); v != "" {
+		bodyweight, err = strconv.ParseFloat(v, 64)
+		if err != nil || bodyweight <= 0 {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid bodyweight")
+			return
+		}
+		bodyweight = units.ToKg(bodyweight, unit)
+	}
+
+	report, err := service.StrengthReportFor(userObjID, basis, bodyweight, months)
+	if err != nil {
+		switch err {
+		case mongo.ErrNoDocuments:
+			utils.ErrorResponse(w, http.StatusNotFound, "No user for this user id")
+		case service.ErrUnknownSex:
+			utils.ErrorResponse(w, http.StatusUnprocessableEntity, "Set gender to male or female to compute scores")
+		case service.ErrNoBodyweight:
+			utils.ErrorResponse(w, http.StatusUnprocessableEntity, "Log a bodyweight or pass one to compute scores")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't compute strength scores")
+		}
+		return
+	}
+
+	service.StrengthReportForDisplay(report, unit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetGoalListHandler lists the user's goals, optionally filtered by status
+// (active, achieved or missed).
+func GetGoalListHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	status := query.Get("status")
+	if status != "" && status != models.GoalActive && status != models.GoalAchieved && status != models.GoalMissed {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	goals, err := database.GetUserGoals(userObjID, status)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't fetch goals")
+		return
+	}
+
+	unit := service.UserWeightUnit(userObjID)
+	for i := range goals {
+		service.GoalForDisplay(&goals[i], unit)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(goals)
+}
+
+// GetTrainingLoadHandler returns daily training load with acute:chronic
+// workload ratio, monotony and strain, for the last 8 weeks unless from/to
+// are given. method is volume (default) or srpe; model is rolling (default)
+// or ewma.
+func GetTrainingLoadHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	method := query.Get("method")
+	if method == "" {
+		method = service.LoadVolume
+	}
+	if method != service.LoadVolume && method != service.LoadSRPE {
+		utils.ErrorResponse(w, http.StatusBadRequest, "method must be volume or srpe")
+		return
+	}
+
+	model := query.Get("model")
+	if model == "" {
+		model = service.ACWRRolling
+	}
+	if model != service.ACWRRolling && model != service.ACWREWMA {
+		utils.ErrorResponse(w, http.StatusBadRequest, "model must be rolling or ewma")
+		return
+	}
+
+	prefs := service.LoadUserPrefs(userObjID)
+	to := prefs.Now()
+	from := to.AddDate(0, 0, -7*8)
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err = utils.ParseDateParamIn(fromStr, false, to.Location())
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid from date")
+			return
+		}
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, err = utils.ParseDateParamIn(toStr, true, to.Location())
+		if err != nil || !to.After(from) {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid to date")
+			return
+		}
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Range can't exceed a year")
+		return
+	}
+
+	report, err := service.TrainingLoadFor(userObjID, from, to, method, model)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't compute training load")
+		return
+	}
+
+	service.TrainingLoadForDisplay(report, prefs.Unit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetPlateausHandler flags the user's stalled and regressing exercises over
+// the last weeks (the configured window by default) with suggested
+// interventions.
+func GetPlateausHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	weeks, err := utils.ParseLimitParam(query.Get("weeks"), 0, 26)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid weeks")
+		return
+	}
+
+	prefs := service.LoadUserPrefs(userObjID)
+	report, err := service.PlateauReportFor(userObjID, weeks, prefs.Now())
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't detect plateaus")
+		return
+	}
+
+	service.PlateauReportForDisplay(report, prefs.Unit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetAnalyticsSeriesHandler returns workouts, sets, reps, volume and tonnage
+// bucketed by day, week, month or year, optionally filtered by exercise,
+// routine and muscle group. Buckets are cut at midnight in the tz time zone,
+// the user's by default.
+func GetAnalyticsSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	prefs := service.LoadUserPrefs(userObjID)
+	filter := analytics.Filter{
+		UserID:      userObjID,
+		Granularity: query.Get("granularity"),
+		Location:    prefs.Location,
+		MuscleGroup: query.Get("muscle"),
+	}
+	if filter.Granularity == "" {
+		filter.Granularity = analytics.Week
+	}
+
+	if tz := query.Get("tz"); tz != "" {
+		if filter.Location, err = time.LoadLocation(tz); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid tz")
+			return
+		}
+	}
+
+	for _, id := range query["exercise_id"] {
+		exerciseObjID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
+			return
+		}
+		filter.Exercises = append(filter.Exercises, exerciseObjID)
+	}
+
+	if routineID := query.Get("routine_id"); routineID != "" {
+		if filter.RoutineID, err = primitive.ObjectIDFromHex(routineID); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid routine_id")
+			return
+		}
+	}
+
+	filter.To = time.Now()
+	if toStr := query.Get("to"); toStr != "" {
+		if filter.To, err = utils.ParseDateParamIn(toStr, true, filter.Location); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid to date")
+			return
+		}
+	}
+	filter.From = filter.To.AddDate(0, 0, -7*12)
+	if fromStr := query.Get("from"); fromStr != "" {
+		if filter.From, err = utils.ParseDateParamIn(fromStr, false, filter.Location); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid from date")
+			return
+		}
+	}
+	if !filter.To.After(filter.From) {
+		utils.ErrorResponse(w, http.StatusBadRequest, "to must be after from")
+		return
+	}
+
+	series, err := analytics.SeriesFor(filter)
+	switch {
+	case err == analytics.ErrInvalidGranularity || err == analytics.ErrInvalidMuscleGroup || err == analytics.ErrTooManyBuckets:
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't compute analytics")
+		return
+	}
+
+	analytics.SeriesForDisplay(series, prefs.Unit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// GetSummaryReportHandler summarises a week or month of the user's training
+// as JSON, or as Markdown or HTML for emails with format=markdown|html. date
+// picks any day of the period; by default the last complete one is reported.
+func GetSummaryReportHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	if userID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+
+	period := query.Get("period")
+	if period == "" {
+		period = service.PeriodWeek
+	}
+	if period != service.PeriodWeek && period != service.PeriodMonth {
+		utils.ErrorResponse(w, http.StatusBadRequest, "period must be week or month")
+		return
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != service.ReportMarkdown && format != service.ReportHTML {
+		utils.ErrorResponse(w, http.StatusBadRequest, "format must be json, markdown or html")
+		return
+	}
+
+	prefs := service.LoadUserPrefs(userObjID)
+	now := prefs.Now()
+	current, _, _ := service.PeriodBounds(period, now)
+	day := current.AddDate(0, 0, -1)
+	if dateStr := query.Get("date"); dateStr != "" {
+		day, err = utils.ParseDateParamIn(dateStr, false, now.Location())
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid date")
+			return
+		}
+		day = day.In(now.Location())
+	}
+
+	report, err := service.SummaryReportFor(userObjID, period, day)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't build report")
+		return
+	}
+
+	service.SummaryReportForDisplay(report, prefs.Unit)
+
+	if format == "" || format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	body, err := service.RenderSummaryReport(report, format)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't render report")
+		return
+	}
+
+	if format == service.ReportHTML {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	}
+	w.Write(body)
 }
@@ -2,17 +2,22 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/middleware"
 	"fitness-tracker/internal/models"
 	"fitness-tracker/internal/service"
+	"fitness-tracker/internal/units"
 	"fitness-tracker/internal/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -36,16 +41,60 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Normalize JSON (snake_case) keys to BSON (camelCase) keys
 	fieldMap := map[string]string{
-		"username":            "username",
-		"email":               "email",
-		"gender":              "gender",
-		"date_of_birth":       "dateOfBirth",
-		"height":              "height",
-		"weight":              "weight",
-		"unit_preference":     "unitPreference",
-		"clearance_level":     "clearanceLevel",
-		"strava_access_token": "stravaAccessToken",
+		"username":             "username",
+		"email":                "email",
+		"gender":               "gender",
+		"date_of_birth":        "dateOfBirth",
+		"height":               "height",
+		"weight":               "weight",
+		"unit_preference":      "unitPreference",
+		"strava_access_token":  "stravaAccessToken",
 		"strava_refresh_token": "stravaRefreshToken",
+		"session_expiry_hours": "sessionExpiryHours",
+		"locale":               "locale",
+		"time_zone":            "timeZone",
+	}
+
+	if locale, ok := incoming["locale"]; ok {
+		value, isString := locale.(string)
+		if !isString || (value != "" && !service.IsSupportedLocale(value)) {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Unsupported locale")
+			return
+		}
+	}
+
+	if hours, ok := incoming["session_expiry_hours"]; ok {
+		value, isNumber := hours.(float64)
+		if !isNumber || !service.ValidSessionExpiryHours(value) {
+			utils.ErrorResponse(w, http.StatusBadRequest, "session_expiry_hours must be a whole number from 0 to "+strconv.Itoa(service.MaxSessionExpiryHours))
+			return
+		}
+	}
+
+	if timeZone, ok := incoming["time_zone"]; ok {
+		value, isString := timeZone.(string)
+		if !isString {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid time_zone")
+			return
+		}
+		if _, err := service.LoadTimeZone(value); value != "" && err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid time_zone")
+			return
+		}
+	}
+
+	// Bodyweight arrives in the user's unit, which may change in this request
+	if weight, ok := incoming["weight"]; ok {
+		value, isNumber := weight.(float64)
+		if !isNumber {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid weight")
+			return
+		}
+		unit := service.UserWeightUnit(userObjID)
+		if preference, ok := incoming["unit_preference"].(string); ok {
+			unit = units.Normalize(preference)
+		}
+		incoming["weight"] = units.ToKg(value, unit)
 	}
 
 	updates := bson.M{}
@@ -81,17 +130,197 @@ func UpdateExerciseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var updates bson.M
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	existing, err := database.GetExerciseData(exerciseObjID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Exercise not found")
+		return
+	}
+
+	if !service.CanEditExercise(userObjID, existing) {
+		utils.ErrorResponse(w, http.StatusForbidden, "Not allowed to modify this exercise")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid JSON body")
 		return
 	}
 
+	var incoming map[string]interface{}
+	var exercise models.Exercise
+	if err := json.Unmarshal(body, &incoming); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if err := json.Unmarshal(body, &exercise); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+
+	if err := service.ValidateTaxonomy(exercise); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid muscle group, movement pattern, force or mechanic")
+		return
+	}
+
+	// Normalize JSON (snake_case) keys to BSON (camelCase) keys
+	fieldMap := map[string]string{
+		"name":              "name",
+		"aliases":           "aliases",
+		"category":          "category",
+		"variations":        "variations",
+		"equipment":         "equipment",
+		"primary_muscles":   "primaryMuscles",
+		"secondary_muscles": "secondaryMuscles",
+		"movement_pattern":  "movementPattern",
+		"force":             "force",
+		"mechanic":          "mechanic",
+		"instructions":      "instructions",
+		"cues":              "cues",
+		"common_mistakes":   "commonMistakes",
+	}
+
+	updates := bson.M{}
+	for k, v := range incoming {
+		if mapped, ok := fieldMap[k]; ok {
+			updates[mapped] = v
+		}
+	}
+	// Translations nest snake_case keys, so the decoded value is stored to
+	// get their BSON names
+	if _, ok := incoming["translations"]; ok {
+		updates["translations"] = exercise.Translations
+	}
+
 	updates["updatedAt"] = time.Now()
 
 	err = database.UpdateExercise(exerciseObjID, updates)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update exercise")
+		if mongo.IsDuplicateKeyError(err) {
+			utils.ErrorResponse(w, http.StatusConflict, "An exercise with this name already exists")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update exercise")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusNoContent, nil)
+}
+
+func PromoteExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	exerciseID := r.URL.Query().Get("exercise_id")
+	if exerciseID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing exercise_id")
+		return
+	}
+
+	exerciseObjID, err := primitive.ObjectIDFromHex(exerciseID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
+		return
+	}
+
+	err = database.PromoteExercise(exerciseObjID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.ErrorResponse(w, http.StatusNotFound, "No custom exercise with this id")
+		} else if mongo.IsDuplicateKeyError(err) {
+			utils.ErrorResponse(w, http.StatusConflict, "A global exercise with this name already exists")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to promote exercise")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusNoContent, nil)
+}
+
+// UpdateUserClearanceHandler sets the clearance level of the user given as
+// target_id. It is the only way to grant admin clearance.
+func UpdateUserClearanceHandler(w http.ResponseWriter, r *http.Request) {
+	targetID := r.URL.Query().Get("target_id")
+	if targetID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing target_id")
+		return
+	}
+
+	targetObjID, err := primitive.ObjectIDFromHex(targetID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid target_id")
+		return
+	}
+
+	var body struct {
+		ClearanceLevel *int `json:"clearance_level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ClearanceLevel == nil || *body.ClearanceLevel < 0 {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid clearance_level")
+		return
+	}
+
+	if _, err := database.GetUserByID(targetObjID); err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	err = database.UpdateUser(targetObjID, bson.M{"clearanceLevel": *body.ClearanceLevel, "updatedAt": time.Now()})
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusNoContent, nil)
+}
+
+// ArchiveExerciseHandler hides an exercise from the catalog, or restores it
+// with archived=false.
+func ArchiveExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	exerciseID := r.URL.Query().Get("exercise_id")
+	if exerciseID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing exercise_id")
+		return
+	}
+
+	exerciseObjID, err := primitive.ObjectIDFromHex(exerciseID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
+		return
+	}
+
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	exercise, err := database.GetExerciseData(exerciseObjID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Exercise not found")
+		return
+	}
+
+	if !service.CanEditExercise(userObjID, exercise) {
+		utils.ErrorResponse(w, http.StatusForbidden, "Not allowed to modify this exercise")
+		return
+	}
+
+	archived := true
+	if value := r.URL.Query().Get("archived"); value != "" {
+		archived, err = strconv.ParseBool(value)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid archived value")
+			return
+		}
+	}
+
+	if err := service.SetExerciseArchived(exercise.ID, archived); err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to archive exercise")
 		return
 	}
 
@@ -120,20 +349,14 @@ func UpdateRoutineHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var updated_exercises []models.RoutineExercise
-	for _, exercise := range updated_exercise_data {
-		exerciseObjID, err := primitive.ObjectIDFromHex(exercise.ExerciseID)
-		if err != nil {
-			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
-			return
+	updated_exercises, err := service.ResolveRoutineExercises(userObjID, updated_exercise_data)
+	if err != nil {
+		if refErr, ok := err.(*service.ExerciseReferenceError); ok {
+			utils.ErrorResponse(w, http.StatusBadRequest, refErr.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't look up exercises")
 		}
-
-		updated_exercises = append(updated_exercises, models.RoutineExercise{
-			ExerciseID: exerciseObjID,
-			Name:       exercise.Name,
-			TargetSets: exercise.TargetSets,
-			TargetReps: exercise.TargetReps,
-		})
+		return
 	}
 
 	updates := bson.M{
@@ -170,32 +393,41 @@ func UpdateSessionHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	session, err := database.GetSessionData(sessionObjID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.ErrorResponse(w, http.StatusNotFound, "Session not found")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't fetch session data")
+		}
+		return
+	}
+
+	if session.Status == models.SessionPaused {
+		utils.ErrorResponse(w, http.StatusConflict, "Session is paused; resume it before updating")
+		return
+	}
+
 	var updated_exercise_data []models.WorkoutExerciseDTO
 	if err := json.NewDecoder(r.Body).Decode(&updated_exercise_data); err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
-	var updated_exercises []models.WorkoutExercise
-	for _, exercise := range updated_exercise_data {
-		exerciseObjID, err := primitive.ObjectIDFromHex(exercise.ExerciseID)
-		if err != nil {
-			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
-			return
+	updated_exercises, err := service.ResolveSessionExercises(session, updated_exercise_data)
+	if err != nil {
+		if refErr, ok := err.(*service.ExerciseReferenceError); ok {
+			utils.ErrorResponse(w, http.StatusBadRequest, refErr.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't look up exercises")
 		}
-		updated_exercises = append(updated_exercises, models.WorkoutExercise{
-			ExerciseID: exerciseObjID,
-			Equipment:  exercise.Equipment,
-			Variation:  exercise.Variation,
-			Sets:       exercise.Sets,
-		})
+		return
 	}
+	service.ExercisesToKg(updated_exercises, service.UserWeightUnit(session.UserID))
 
-	updates := bson.M{
-		"exercises":     updated_exercises,
-		"exerciseIndex": exIndex,
-		"lastUpdated":   primitive.NewDateTimeFromTime(time.Now()),
-	}
+	updates := service.TouchSession(session)
+	updates["exercises"] = updated_exercises
+	updates["exerciseIndex"] = exIndex
 
 	err = database.UpdateSession(sessionObjID, updates)
 	if err != nil {
@@ -223,3 +455,275 @@ func UpdateExerciseHistoryHandler(w http.ResponseWriter, r *http.Request) {
 
 	utils.JSONResponse(w, http.StatusNoContent, nil)
 }
+
+func SubstituteSessionExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("session_id")
+	exerciseIndexStr := r.URL.Query().Get("exercise_index")
+	exerciseID := r.URL.Query().Get("exercise_id")
+
+	if sessionID == "" || exerciseIndexStr == "" || exerciseID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing session_id, exercise_index or exercise_id")
+		return
+	}
+
+	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
+	exerciseObjID, err2 := primitive.ObjectIDFromHex(exerciseID)
+	if err != nil || err2 != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	exIndex, err := strconv.Atoi(exerciseIndexStr)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_index")
+		return
+	}
+
+	exercise, err := service.SubstituteExercise(sessionObjID, exIndex, exerciseObjID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.ErrorResponse(w, http.StatusNotFound, "Session or exercise not found")
+		} else if err == service.ErrExerciseIndex {
+			utils.ErrorResponse(w, http.StatusBadRequest, "exercise_index out of range")
+		} else if err == service.ErrSessionState {
+			utils.ErrorResponse(w, http.StatusConflict, "Session is not active")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to substitute exercise")
+		}
+		return
+	}
+
+	unit := units.Kg
+	if session, err := database.GetSessionData(sessionObjID); err == nil {
+		unit = service.UserWeightUnit(session.UserID)
+	}
+	service.SetsForDisplay(exercise.Sets, unit)
+
+	utils.JSONResponse(w, http.StatusOK, exercise)
+}
+
+func UpdateSessionNotesHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing session_id")
+		return
+	}
+
+	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid session_id format")
+		return
+	}
+
+	var notes models.SessionNotesDTO
+	if err := json.NewDecoder(r.Body).Decode(&notes); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+
+	err = service.UpdateSessionNotes(sessionObjID, notes)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.ErrorResponse(w, http.StatusNotFound, "Session not found")
+		} else if err == service.ErrInvalidMetadata {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid metadata: bodyweight must be positive and difficulty between 1 and 10")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update session")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusNoContent, nil)
+}
+
+func PauseSessionHandler(w http.ResponseWriter, r *http.Request) {
+	changeSessionState(w, r, service.PauseSession, "Session is not active")
+}
+
+func ResumeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	changeSessionState(w, r, service.ResumeSession, "Session is not paused")
+}
+
+func AbandonSessionHandler(w http.ResponseWriter, r *http.Request) {
+	changeSessionState(w, r, service.AbandonSession, "Session can't be abandoned")
+}
+
+func changeSessionState(w http.ResponseWriter, r *http.Request, action func(primitive.ObjectID) error, conflictMessage string) {
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing session_id")
+		return
+	}
+
+	sessionObjID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid session_id format")
+		return
+	}
+
+	err = action(sessionObjID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.ErrorResponse(w, http.StatusNotFound, "Session not found")
+		} else if err == service.ErrSessionState {
+			utils.ErrorResponse(w, http.StatusConflict, conflictMessage)
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update session")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusNoContent, nil)
+}
+
+// UpdateEquipmentHandler replaces the user's equipment inventory with the
+// request body. Weights are in the body's "unit".
+func UpdateEquipmentHandler(w http.ResponseWriter, r *http.Request) {
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	var inventory models.EquipmentInventory
+	if err := json.NewDecoder(r.Body).Decode(&inventory); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if err := service.ValidateInventory(&inventory); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid bars, plates, dumbbells or machine step")
+		return
+	}
+	inventory.UserID = userObjID
+
+	if err := database.UpsertEquipmentInventory(inventory); err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to save equipment inventory")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, inventory)
+}
+
+func UpdateMeasurementHandler(w http.ResponseWriter, r *http.Request) {
+	measurementID := r.URL.Query().Get("measurement_id")
+	if measurementID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing measurement_id")
+		return
+	}
+
+	measurementObjID, err := primitive.ObjectIDFromHex(measurementID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid measurement_id")
+		return
+	}
+
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	var dto models.BodyMeasurementDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	measurement, err := database.GetMeasurement(measurementObjID, userObjID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.ErrorResponse(w, http.StatusNotFound, "Measurement not found")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't load measurement")
+		}
+		return
+	}
+
+	prefs := service.LoadUserPrefs(userObjID)
+	unit := prefs.Unit
+	if err := service.ApplyMeasurementDTO(&measurement, dto, unit, prefs.Location); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid date, bodyweight, body fat or circumference")
+		return
+	}
+
+	if err := database.ReplaceMeasurement(measurement); err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update measurement")
+		return
+	}
+
+	if err := service.SyncProfileBodyweight(userObjID); err != nil {
+		log.Println("Failed to sync profile bodyweight", err)
+	}
+	if err := service.EvaluateGoals(userObjID); err != nil {
+		log.Println("Failed to evaluate goals", err)
+	}
+
+	service.MeasurementForDisplay(&measurement, unit)
+	utils.JSONResponse(w, http.StatusOK, measurement)
+}
+
+// UpdateGoalHandler changes a goal's target or deadline and re-evaluates it.
+func UpdateGoalHandler(w http.ResponseWriter, r *http.Request) {
+	goalID := r.URL.Query().Get("goal_id")
+	if goalID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing goal_id")
+		return
+	}
+
+	goalObjID, err := primitive.ObjectIDFromHex(goalID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid goal_id")
+		return
+	}
+
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	var dto models.GoalDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	goal, err := database.GetGoal(goalObjID, userObjID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.ErrorResponse(w, http.StatusNotFound, "Goal not found")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't load goal")
+		}
+		return
+	}
+
+	unit := service.UserWeightUnit(userObjID)
+	if err := service.UpdateGoalTarget(&goal, dto.Target, dto.Deadline, unit); err != nil {
+		if err == service.ErrInvalidGoal {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid target or deadline")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't measure goal progress")
+		}
+		return
+	}
+
+	err = database.UpdateGoal(goal.ID, bson.M{
+		"target":      goal.Target,
+		"deadline":    goal.Deadline,
+		"current":     goal.Current,
+		"progress":    goal.Progress,
+		"status":      goal.Status,
+		"projectedAt": goal.ProjectedAt,
+		"achievedAt":  goal.AchievedAt,
+		"evaluatedAt": goal.EvaluatedAt,
+	})
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update goal")
+		return
+	}
+
+	service.GoalForDisplay(&goal, unit)
+	utils.JSONResponse(w, http.StatusOK, goal)
+}
@@ -6,12 +6,16 @@ import (
 	"net/http"
 	"time"
 
+	"fitness-tracker/internal/config"
 	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/middleware"
 	"fitness-tracker/internal/models"
 	"fitness-tracker/internal/service"
+	"fitness-tracker/internal/units"
 	"fitness-tracker/internal/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -22,6 +26,21 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Clearance is granted by an admin, never at sign-up
+	user.ID = primitive.NilObjectID
+	user.ClearanceLevel = 0
+
+	if user.TimeZone != "" {
+		if _, err := service.LoadTimeZone(user.TimeZone); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid time_zone")
+			return
+		}
+	}
+
+	// Bodyweight arrives in the user's preferred unit
+	user.Weight = units.ToKg(user.Weight, units.Normalize(user.UnitPreference))
+	user.WeightStorage = units.Kg
+
 	userID, err := database.CreateUser(user)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create user")
@@ -32,6 +51,12 @@ func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func CreateExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
 	var exercise models.Exercise
 
 	if err := json.NewDecoder(r.Body).Decode(&exercise); err != nil {
@@ -39,15 +64,74 @@ func CreateExerciseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := service.ValidateTaxonomy(exercise); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid muscle group, movement pattern, force or mechanic")
+		return
+	}
+
+	// Exercises are private to their creator unless an admin asks for a
+	// global one. Media is only attached through uploads, and slugs and the
+	// archived flag belong to the catalog.
+	exercise.ID = primitive.NilObjectID
+	exercise.Media = nil
+	exercise.OwnerID = userObjID
+	if r.URL.Query().Get("global") == "true" {
+		if !service.IsAdmin(userObjID) {
+			utils.ErrorResponse(w, http.StatusForbidden, "Only admins can add to the global catalog")
+			return
+		}
+		exercise.OwnerID = primitive.NilObjectID
+	} else {
+		exercise.Slug = ""
+		exercise.Archived = false
+	}
+
 	exerciseID, err := database.CreateExercise(exercise)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create exercise")
+		if mongo.IsDuplicateKeyError(err) {
+			utils.ErrorResponse(w, http.StatusConflict, "An exercise with this name already exists")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create exercise")
+		}
 		return
 	}
 
 	utils.JSONResponse(w, http.StatusCreated, exerciseID.Hex())
 }
 
+func MergeExercisesHandler(w http.ResponseWriter, r *http.Request) {
+	sourceID := r.URL.Query().Get("source_id")
+	targetID := r.URL.Query().Get("target_id")
+
+	if sourceID == "" || targetID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing source_id or target_id")
+		return
+	}
+
+	sourceObjID, err := primitive.ObjectIDFromHex(sourceID)
+	targetObjID, err2 := primitive.ObjectIDFromHex(targetID)
+	if err != nil || err2 != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	report, err := service.MergeExercises(sourceObjID, targetObjID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.ErrorResponse(w, http.StatusNotFound, "Exercise not found")
+		} else if err == service.ErrInvalidMerge {
+			utils.ErrorResponse(w, http.StatusBadRequest, "source_id and target_id must differ")
+		} else if err == service.ErrMergeScope {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to merge exercises")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, report)
+}
+
 func CreateRoutineHandler(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	userObjID, err := primitive.ObjectIDFromHex(userID)
@@ -62,20 +146,14 @@ func CreateRoutineHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var exercises []models.RoutineExercise
-	for _, exercise := range routine_data.Exercises {
-		exerciseObjID, err := primitive.ObjectIDFromHex(exercise.ExerciseID)
-		if err != nil {
-			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
-			return
+	exercises, err := service.ResolveRoutineExercises(userObjID, routine_data.Exercises)
+	if err != nil {
+		if refErr, ok := err.(*service.ExerciseReferenceError); ok {
+			utils.ErrorResponse(w, http.StatusBadRequest, refErr.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't look up exercises")
 		}
-
-		exercises = append(exercises, models.RoutineExercise{
-			ExerciseID: exerciseObjID,
-			Name:       exercise.Name,
-			TargetSets: exercise.TargetSets,
-			TargetReps: exercise.TargetReps,
-		})
+		return
 	}
 
 	newRoutine := models.FullRoutine{
@@ -135,12 +213,10 @@ func CreateWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	workout := models.FullWorkout{
-		ID:          primitive.NilObjectID,
-		UserID:      workout_session.UserID,
-		RoutineID:   workout_session.RoutineID,
-		WorkoutDate: primitive.NewDateTimeFromTime(time.Now()),
-		Exercises:   workout_session.Exercises,
+	workout := service.WorkoutFromSession(workout_session)
+	if err := service.SetLocalStart(&workout, r.URL.Query().Get("started_at")); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid started_at")
+		return
 	}
 
 	workoutID, err := database.CreateWorkout(workout)
@@ -149,6 +225,10 @@ func CreateWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := service.EvaluateGoals(workout.UserID); err != nil {
+		log.Println("Failed to evaluate goals", err)
+	}
+
 	utils.JSONResponse(w, http.StatusCreated, workoutID.Hex())
 }
 
@@ -187,3 +267,149 @@ func CreateExerciseHistoryHandler(w http.ResponseWriter, r *http.Request) {
 
 	utils.JSONResponse(w, http.StatusCreated, historyID.Hex())
 }
+
+// SyncExercisesHandler reconciles the global catalog with the exercises JSON
+// file. Pass dry_run=true to preview the changes.
+func SyncExercisesHandler(w http.ResponseWriter, r *http.Request) {
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	report, err := database.SyncExercisesFromJSON(r.Context(), database.MongoDatabase, config.AppConfig.ExercisesJSONPath, dryRun)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to sync exercises")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, report)
+}
+
+// UploadExerciseMediaHandler stores a demo image or video sent as the "file"
+// field of a multipart form and attaches it to the exercise.
+func UploadExerciseMediaHandler(w http.ResponseWriter, r *http.Request) {
+	exerciseID := r.URL.Query().Get("exercise_id")
+	if exerciseID == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing exercise_id")
+		return
+	}
+
+	exerciseObjID, err := primitive.ObjectIDFromHex(exerciseID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid exercise_id")
+		return
+	}
+
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	exercise, err := database.GetExerciseData(exerciseObjID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Exercise not found")
+		return
+	}
+
+	if !service.CanEditExercise(userObjID, exercise) {
+		utils.ErrorResponse(w, http.StatusForbidden, "Not allowed to modify this exercise")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxUploadBytes()+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing file or upload too large")
+		return
+	}
+	defer file.Close()
+
+	media, err := service.UploadExerciseMedia(exercise, userObjID, file, header.Header.Get("Content-Type"), r.FormValue("caption"))
+	if err != nil {
+		switch err {
+		case service.ErrUnsupportedMedia:
+			utils.ErrorResponse(w, http.StatusUnsupportedMediaType, "Unsupported media type")
+		case service.ErrMediaTooLarge:
+			utils.ErrorResponse(w, http.StatusRequestEntityTooLarge, "Media file too large")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to store media")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusCreated, media)
+}
+
+// CreateMeasurementHandler logs bodyweight, body fat and circumferences in the
+// user's units. The date defaults to now.
+func CreateMeasurementHandler(w http.ResponseWriter, r *http.Request) {
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	var dto models.BodyMeasurementDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	measurement := models.BodyMeasurement{
+		UserID: userObjID,
+		Date:   primitive.NewDateTimeFromTime(time.Now()),
+	}
+	prefs := service.LoadUserPrefs(userObjID)
+	if err := service.ApplyMeasurementDTO(&measurement, dto, prefs.Unit, prefs.Location); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid date, bodyweight, body fat or circumference")
+		return
+	}
+
+	measurementID, err := database.CreateMeasurement(measurement)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create measurement")
+		return
+	}
+
+	if measurement.Bodyweight > 0 {
+		if err := service.SyncProfileBodyweight(userObjID); err != nil {
+			log.Println("Failed to sync profile bodyweight", err)
+		}
+		if err := service.EvaluateGoals(userObjID); err != nil {
+			log.Println("Failed to evaluate goals", err)
+		}
+	}
+
+	utils.JSONResponse(w, http.StatusCreated, measurementID.Hex())
+}
+
+// CreateGoalHandler sets a goal. Weight targets are in the user's unit.
+func CreateGoalHandler(w http.ResponseWriter, r *http.Request) {
+	userObjID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+		return
+	}
+
+	var dto models.GoalDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	goal, err := service.NewGoal(userObjID, dto, service.UserWeightUnit(userObjID))
+	if err != nil {
+		if err == service.ErrInvalidGoal {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid goal type, exercise, target or deadline")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Couldn't measure goal progress")
+		}
+		return
+	}
+
+	goalID, err := database.CreateGoal(goal)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create goal")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusCreated, goalID.Hex())
+}
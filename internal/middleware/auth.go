@@ -4,6 +4,8 @@ import (
 	"context"
 	"net/http"
 
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
 	"fitness-tracker/internal/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -33,3 +35,34 @@ func RequireUser(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequireAdmin must be wrapped by RequireUser. It loads the user and responds
+// 403 unless their clearance level allows catalog administration.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := UserIDFromContext(r.Context())
+		if !ok {
+			utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: missing user_id")
+			return
+		}
+		user, err := database.GetUserByID(userID)
+		if err != nil || user.ClearanceLevel < models.AdminClearance {
+			utils.ErrorResponse(w, http.StatusForbidden, "Forbidden: admin clearance required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UserIDFromContext returns the user ID attached by RequireUser.
+func UserIDFromContext(ctx context.Context) (primitive.ObjectID, bool) {
+	userID, ok := ctx.Value(UserIDKey).(string)
+	if !ok {
+		return primitive.NilObjectID, false
+	}
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, false
+	}
+	return userObjID, true
+}
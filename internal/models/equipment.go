@@ -0,0 +1,40 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// EquipmentInventory describes the equipment a user trains with. Weights are
+// in Unit, the unit the gym's plates and dumbbells are labelled in, which can
+// differ from the user's display preference.
+type EquipmentInventory struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"userID" json:"user_id"`
+	Unit      string             `bson:"unit" json:"unit"`
+	Equipment []string           `bson:"equipment" json:"equipment"` // catalog equipment names the user has access to
+	Bars      []Bar              `bson:"bars" json:"bars"`
+	Plates    []PlateStock       `bson:"plates" json:"plates"`
+	Dumbbells *DumbbellRange     `bson:"dumbbells,omitempty" json:"dumbbells,omitempty"`
+	// MachineStep is the weight stack increment of selectorised machines and
+	// cable stations.
+	MachineStep float64            `bson:"machineStep,omitempty" json:"machine_step,omitempty"`
+	UpdatedAt   primitive.DateTime `bson:"updatedAt" json:"updated_at"`
+}
+
+// Bar is a barbell by equipment name ("Barbell", "EZ Bar", "Trap Bar").
+type Bar struct {
+	Name   string  `bson:"name" json:"name"`
+	Weight float64 `bson:"weight" json:"weight"`
+}
+
+// PlateStock is how many plates of one weight are available. Plates are
+// loaded in pairs, so an odd plate out is never used.
+type PlateStock struct {
+	Weight float64 `bson:"weight" json:"weight"`
+	Count  int     `bson:"count" json:"count"`
+}
+
+// DumbbellRange covers fixed dumbbells from Min to Max in Increment steps.
+type DumbbellRange struct {
+	Min       float64 `bson:"min" json:"min"`
+	Max       float64 `bson:"max" json:"max"`
+	Increment float64 `bson:"increment" json:"increment"`
+}
@@ -2,12 +2,78 @@ package models
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// Kinds of exercise demo media.
+const (
+	MediaImage = "image"
+	MediaVideo = "video"
+)
+
+// Muscle groups used by the exercise taxonomy.
+var MuscleGroups = []string{
+	"chest", "front_delts", "side_delts", "rear_delts", "triceps", "biceps", "forearms",
+	"lats", "upper_back", "traps", "lower_back", "abs", "obliques",
+	"glutes", "quads", "hamstrings", "adductors", "calves",
+}
+
+// Movement patterns, force types and mechanics used by the exercise taxonomy.
+var (
+	MovementPatterns = []string{"push", "pull", "hinge", "squat", "lunge", "carry", "core", "mobility"}
+	ForceTypes       = []string{"push", "pull", "static"}
+	Mechanics        = []string{"compound", "isolation"}
+)
+
 type Exercise struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name       string             `bson:"name" json:"name"`
-	Category   string             `bson:"category" json:"category"`
-	Variations []string           `bson:"variations" json:"variations"`
-	Equipment  []string           `bson:"equipment" json:"equipment"`
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name             string             `bson:"name" json:"name"`
+	Slug             string             `bson:"slug,omitempty" json:"slug,omitempty"` // stable catalog key, survives renames
+	Aliases          []string           `bson:"aliases,omitempty" json:"aliases"`
+	Category         string             `bson:"category" json:"category"`
+	Variations       []string           `bson:"variations" json:"variations"`
+	Equipment        []string           `bson:"equipment" json:"equipment"`
+	PrimaryMuscles   []string           `bson:"primaryMuscles,omitempty" json:"primary_muscles"`
+	SecondaryMuscles []string           `bson:"secondaryMuscles,omitempty" json:"secondary_muscles"`
+	MovementPattern  string             `bson:"movementPattern,omitempty" json:"movement_pattern"`
+	Force            string             `bson:"force,omitempty" json:"force"`
+	Mechanic         string             `bson:"mechanic,omitempty" json:"mechanic"`
+	OwnerID          primitive.ObjectID `bson:"ownerID,omitempty" json:"owner_id,omitempty"`  // unset for the global catalog
+	Archived         bool               `bson:"archived,omitempty" json:"archived,omitempty"` // hidden from search, still resolvable by ID
+
+	Instructions   []string        `bson:"instructions,omitempty" json:"instructions"`
+	Cues           []string        `bson:"cues,omitempty" json:"cues"`
+	CommonMistakes []string        `bson:"commonMistakes,omitempty" json:"common_mistakes"`
+	Media          []ExerciseMedia `bson:"media,omitempty" json:"media"`
+
+	// Translations are keyed by language ("de", "es"); missing entries fall
+	// back to the English fields above.
+	Translations map[string]ExerciseTranslation `bson:"translations,omitempty" json:"translations,omitempty"`
+	Locale       string                         `bson:"-" json:"locale,omitempty"` // set on localized responses
+}
+
+// ExerciseTranslation holds the localized catalog content of an exercise.
+// Variations and equipment map the English value to its translation, so the
+// English values stay the canonical ones stored in routines and workouts.
+type ExerciseTranslation struct {
+	Name       string            `bson:"name,omitempty" json:"name,omitempty"`
+	Aliases    []string          `bson:"aliases,omitempty" json:"aliases,omitempty"`
+	Category   string            `bson:"category,omitempty" json:"category,omitempty"`
+	Variations map[string]string `bson:"variations,omitempty" json:"variations,omitempty"`
+	Equipment  map[string]string `bson:"equipment,omitempty" json:"equipment,omitempty"`
+
+	Instructions   []string `bson:"instructions,omitempty" json:"instructions,omitempty"`
+	Cues           []string `bson:"cues,omitempty" json:"cues,omitempty"`
+	CommonMistakes []string `bson:"commonMistakes,omitempty" json:"common_mistakes,omitempty"`
+}
+
+// ExerciseMedia references a demo image or video in the blob store.
+type ExerciseMedia struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Kind        string             `bson:"kind" json:"kind"`
+	Key         string             `bson:"key" json:"-"`
+	ContentType string             `bson:"contentType" json:"content_type"`
+	Size        int64              `bson:"size" json:"size"`
+	Caption     string             `bson:"caption,omitempty" json:"caption,omitempty"`
+	UploadedBy  primitive.ObjectID `bson:"uploadedBy" json:"uploaded_by"`
+	UploadedAt  primitive.DateTime `bson:"uploadedAt" json:"uploaded_at"`
 }
 
 type ExerciseSets struct {
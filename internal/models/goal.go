@@ -0,0 +1,49 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Goal types. Weight targets are in kilograms.
+const (
+	GoalExerciseE1RM    = "exercise_e1rm"     // estimated one-rep max on ExerciseID
+	GoalBodyweight      = "bodyweight"        // reach a bodyweight, up or down from Start
+	GoalWorkoutsPerWeek = "workouts_per_week" // workouts in the last seven days
+	GoalMonthlyVolume   = "monthly_volume"    // volume lifted in the current calendar month
+)
+
+// Goal states. Missed goals passed their deadline without being achieved.
+const (
+	GoalActive   = "active"
+	GoalAchieved = "achieved"
+	GoalMissed   = "missed"
+)
+
+var GoalTypes = []string{GoalExerciseE1RM, GoalBodyweight, GoalWorkoutsPerWeek, GoalMonthlyVolume}
+
+// Goal is a target the user works towards. Current, Progress and
+// ProjectedAt are refreshed whenever the user saves a workout or a body log.
+type Goal struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"userID" json:"user_id"`
+	Type        string             `bson:"type" json:"type"`
+	ExerciseID  primitive.ObjectID `bson:"exerciseID,omitempty" json:"exercise_id,omitempty"`
+	Target      float64            `bson:"target" json:"target"`
+	Start       float64            `bson:"start" json:"start"` // value when the goal was set
+	Deadline    primitive.DateTime `bson:"deadline,omitempty" json:"deadline,omitempty"`
+	Status      string             `bson:"status" json:"status"`
+	Current     float64            `bson:"current" json:"current"`
+	Progress    float64            `bson:"progress" json:"progress"` // 0 to 1
+	ProjectedAt primitive.DateTime `bson:"projectedAt,omitempty" json:"projected_at,omitempty"`
+	AchievedAt  primitive.DateTime `bson:"achievedAt,omitempty" json:"achieved_at,omitempty"`
+	CreatedAt   primitive.DateTime `bson:"createdAt" json:"created_at"`
+	EvaluatedAt primitive.DateTime `bson:"evaluatedAt,omitempty" json:"evaluated_at,omitempty"`
+	Unit        string             `bson:"-" json:"unit,omitempty"` // weight unit of a response
+}
+
+// GoalDTO is a goal as sent by clients, with weight targets in the user's
+// unit and the deadline as a date.
+type GoalDTO struct {
+	Type       string  `json:"type"`
+	ExerciseID string  `json:"exercise_id"`
+	Target     float64 `json:"target"`
+	Deadline   string  `json:"deadline"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// MeasurementSites are the accepted keys of BodyMeasurement.Circumferences.
+var MeasurementSites = []string{
+	"neck", "shoulders", "chest", "waist", "hips",
+	"left_arm", "right_arm", "left_forearm", "right_forearm",
+	"left_thigh", "right_thigh", "left_calf", "right_calf",
+}
+
+// BodyMeasurement is one entry of a user's bodyweight and measurement log.
+// Every value is optional. Bodyweight is stored in kilograms and
+// circumferences in centimetres.
+type BodyMeasurement struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID `bson:"userID" json:"user_id"`
+	Date           primitive.DateTime `bson:"date" json:"date"`
+	Bodyweight     float64            `bson:"bodyweight,omitempty" json:"bodyweight,omitempty"`
+	BodyFat        float64            `bson:"bodyFat,omitempty" json:"body_fat,omitempty"` // percent
+	Circumferences map[string]float64 `bson:"circumferences,omitempty" json:"circumferences,omitempty"`
+	Notes          string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	Unit           string             `bson:"-" json:"unit,omitempty"`        // weight unit of a response
+	LengthUnit     string             `bson:"-" json:"length_unit,omitempty"` // circumference unit of a response
+}
+
+// BodyMeasurementDTO is a log entry as sent by clients, in the user's units.
+// On updates only the fields present are changed; a zero value clears one.
+type BodyMeasurementDTO struct {
+	Date           string             `json:"date"`
+	Bodyweight     *float64           `json:"bodyweight"`
+	BodyFat        *float64           `json:"body_fat"`
+	Circumferences map[string]float64 `json:"circumferences"`
+	Notes          *string            `json:"notes"`
+}
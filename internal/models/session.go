@@ -4,6 +4,14 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Session lifecycle states.
+const (
+	SessionActive    = "active"
+	SessionPaused    = "paused"
+	SessionAbandoned = "abandoned"
+	SessionExpired   = "expired"
+)
+
 type WorkoutSession struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID        primitive.ObjectID `bson:"userID" json:"user_id"`
@@ -11,4 +19,21 @@ type WorkoutSession struct {
 	Exercises     []WorkoutExercise  `bson:"exercises" json:"exercises"`
 	ExerciseIndex int                `bson:"exerciseIndex" json:"exercise_index"`
 	LastUpdate    primitive.DateTime `bson:"lastUpdated" json:"last_update"`
+	Status        string             `bson:"status,omitempty" json:"status,omitempty"`
+	StartedAt     primitive.DateTime `bson:"startedAt,omitempty" json:"started_at,omitempty"`
+	PausedAt      primitive.DateTime `bson:"pausedAt,omitempty" json:"paused_at,omitempty"`
+	ExpiresAt     primitive.DateTime `bson:"expiresAt,omitempty" json:"expires_at,omitempty"`
+	ArchivedAt    primitive.DateTime `bson:"archivedAt,omitempty" json:"archived_at,omitempty"`
+	Notes         string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	Tags          []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	Metadata      *WorkoutMetadata   `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	Unit          string             `bson:"-" json:"unit,omitempty"` // weight unit of a response
+}
+
+// SessionNotesDTO carries the free-text notes, tags and metadata a user
+// attaches to a session.
+type SessionNotesDTO struct {
+	Notes    *string          `json:"notes"`
+	Tags     []string         `json:"tags"`
+	Metadata *WorkoutMetadata `json:"metadata"`
 }
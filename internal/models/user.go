@@ -2,6 +2,9 @@ package models
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// AdminClearance is the minimum clearance level for catalog administration.
+const AdminClearance = 2
+
 type User struct {
 	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Username           string             `bson:"username" json:"username"`
@@ -14,4 +17,8 @@ type User struct {
 	ClearanceLevel     int                `bson:"clearanceLevel" json:"clearance_level"`
 	StravaAccessToken  string             `bson:"stravaAccessToken" json:"strava_access_token"`
 	StravaRefreshToken string             `bson:"stravaRefreshToken" json:"strava_refresh_token"`
+	SessionExpiryHours int                `bson:"sessionExpiryHours,omitempty" json:"session_expiry_hours,omitempty"`
+	Locale             string             `bson:"locale,omitempty" json:"locale,omitempty"`
+	TimeZone           string             `bson:"timeZone,omitempty" json:"time_zone,omitempty"` // IANA name, UTC when unset
+	WeightStorage      string             `bson:"weightStorage,omitempty" json:"-"`              // "kg" once weights are stored in kilograms
 }
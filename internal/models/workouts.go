@@ -7,11 +7,22 @@ type Workout struct {
 	UserID      primitive.ObjectID `bson:"userID" json:"user_id"`
 	RoutineID   primitive.ObjectID `bson:"routineID" json:"routine_id"`
 	WorkoutDate primitive.DateTime `bson:"workoutDate" json:"workout_date"`
+	Partial     bool               `bson:"partial,omitempty" json:"partial,omitempty"`
+	Notes       string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	Tags        []string           `bson:"tags,omitempty" json:"tags,omitempty"`
 }
 
 type WorkoutSet struct {
 	Reps   int     `bson:"reps" json:"reps"`
 	Weight float64 `bson:"weight" json:"weight"`
+	Notes  string  `bson:"notes,omitempty" json:"notes,omitempty"`
+}
+
+// WorkoutMetadata is optional context the user records about a session.
+type WorkoutMetadata struct {
+	Location   string  `bson:"location,omitempty" json:"location,omitempty"`
+	Bodyweight float64 `bson:"bodyweight,omitempty" json:"bodyweight,omitempty"`
+	Difficulty int     `bson:"difficulty,omitempty" json:"difficulty,omitempty"` // perceived, 1-10
 }
 
 type WorkoutExercise struct {
@@ -20,6 +31,10 @@ type WorkoutExercise struct {
 	Variation  string             `bson:"variation" json:"variation"`
 	Sets       []WorkoutSet       `bson:"sets" json:"sets"`
 	Name       string             `bson:"name" json:"name"`
+	Notes      string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	// EquipmentOptions are the exercise's equipment choices the user has
+	// access to; only set on sessions.
+	EquipmentOptions []string `bson:"equipmentOptions,omitempty" json:"equipment_options,omitempty"`
 }
 
 type WorkoutExerciseDTO struct {
@@ -28,6 +43,7 @@ type WorkoutExerciseDTO struct {
 	Variation  string       `json:"variation"`
 	Sets       []WorkoutSet `json:"sets"`
 	Name       string       `json:"name"`
+	Notes      string       `json:"notes,omitempty"`
 }
 
 type FullWorkout struct {
@@ -36,4 +52,35 @@ type FullWorkout struct {
 	RoutineID   primitive.ObjectID `bson:"routineID" json:"routine_id"`
 	WorkoutDate primitive.DateTime `bson:"workoutDate" json:"workout_date"`
 	Exercises   []WorkoutExercise  `bson:"exercises" json:"exercises"`
+	Partial     bool               `bson:"partial,omitempty" json:"partial,omitempty"`
+	Notes       string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	Tags        []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	Metadata    *WorkoutMetadata   `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	StartedAt   primitive.DateTime `bson:"startedAt,omitempty" json:"started_at,omitempty"`
+	// LocalStart is the start time on the lifter's clock, RFC 3339 with their
+	// UTC offset, which decides the day the workout counts for.
+	LocalStart string `bson:"localStart,omitempty" json:"local_start,omitempty"`
+	Unit       string `bson:"-" json:"unit,omitempty"` // weight unit of a response
+}
+
+// WorkoutSummary is the list projection of a workout: enough for list screens
+// without loading every set.
+type WorkoutSummary struct {
+	ID              primitive.ObjectID `bson:"_id" json:"id"`
+	UserID          primitive.ObjectID `bson:"userID" json:"user_id"`
+	RoutineID       primitive.ObjectID `bson:"routineID" json:"routine_id"`
+	WorkoutDate     primitive.DateTime `bson:"workoutDate" json:"workout_date"`
+	LocalStart      string             `bson:"localStart,omitempty" json:"local_start,omitempty"`
+	Partial         bool               `bson:"partial,omitempty" json:"partial,omitempty"`
+	Notes           string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	Tags            []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	ExerciseCount   int                `bson:"exerciseCount" json:"exercise_count"`
+	TotalVolume     float64            `bson:"totalVolume" json:"total_volume"`
+	DurationSeconds int64              `bson:"durationSeconds" json:"duration_seconds"`
+}
+
+type WorkoutSummaryPage struct {
+	Workouts   []WorkoutSummary `json:"workouts"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	Unit       string           `json:"unit"`
 }
@@ -14,6 +14,7 @@ func RegisterRoutes(mux *http.ServeMux) {
 	mux.Handle("/user/all", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetAllUsersHandler)))
 	mux.Handle("/user/create", middleware.AllowMethods([]string{"POST"}, http.HandlerFunc(handlers.CreateUserHandler)))
 	mux.Handle("/user/update", middleware.RequireUser(middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.UpdateUserHandler))))
+	mux.Handle("/user/clearance", middleware.RequireUser(middleware.RequireAdmin(middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.UpdateUserClearanceHandler)))))
 
 	// OVERSEER removed
 
@@ -21,9 +22,21 @@ func RegisterRoutes(mux *http.ServeMux) {
 	mux.Handle("/exercise/id", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetExerciseIDHandler)))
 	mux.Handle("/exercise/name", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetExerciseNameHandler)))
 	mux.Handle("/exercise/list", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetExerciseListHandler)))
+	mux.Handle("/v2/exercise/list", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetExerciseSearchHandler)))
 	mux.Handle("/exercise/data", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetExerciseDataHandler)))
-	mux.Handle("/exercise/create", middleware.AllowMethods([]string{"POST"}, http.HandlerFunc(handlers.CreateExerciseHandler)))
-	mux.Handle("/exercise/update", middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.UpdateExerciseHandler)))
+	mux.Handle("/exercise/create", middleware.RequireUser(middleware.AllowMethods([]string{"POST"}, http.HandlerFunc(handlers.CreateExerciseHandler))))
+	mux.Handle("/exercise/update", middleware.RequireUser(middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.UpdateExerciseHandler))))
+	mux.Handle("/exercise/references", middleware.RequireUser(middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetExerciseReferencesHandler))))
+	mux.Handle("/exercise/archive", middleware.RequireUser(middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.ArchiveExerciseHandler))))
+	mux.Handle("/exercise/delete", middleware.RequireUser(middleware.AllowMethods([]string{"DELETE"}, http.HandlerFunc(handlers.DeleteExerciseHandler))))
+	mux.Handle("/exercise/media", middleware.RequireUser(middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetExerciseMediaHandler))))
+	mux.Handle("/exercise/media/types", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetMediaTypesHandler)))
+	mux.Handle("/exercise/media/upload", middleware.RequireUser(middleware.AllowMethods([]string{"POST"}, http.HandlerFunc(handlers.UploadExerciseMediaHandler))))
+	mux.Handle("/exercise/media/delete", middleware.RequireUser(middleware.AllowMethods([]string{"DELETE"}, http.HandlerFunc(handlers.DeleteExerciseMediaHandler))))
+	mux.Handle("/exercise/merge", middleware.RequireUser(middleware.RequireAdmin(middleware.AllowMethods([]string{"POST"}, http.HandlerFunc(handlers.MergeExercisesHandler)))))
+	mux.Handle("/exercise/duplicates", middleware.RequireUser(middleware.RequireAdmin(middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetDuplicateExercisesHandler)))))
+	mux.Handle("/exercise/sync", middleware.RequireUser(middleware.RequireAdmin(middleware.AllowMethods([]string{"POST"}, http.HandlerFunc(handlers.SyncExercisesHandler)))))
+	mux.Handle("/exercise/promote", middleware.RequireUser(middleware.RequireAdmin(middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.PromoteExerciseHandler)))))
 
 	// ROUTINE
 	mux.Handle("/routines/list", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetRoutineListHandler)))
@@ -37,12 +50,20 @@ func RegisterRoutes(mux *http.ServeMux) {
 	mux.Handle("/workouts/data", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetWorkoutDataHandler)))
 	mux.Handle("/workouts/count", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.CountWorkoutHandler)))
 	mux.Handle("/workouts/create", middleware.RequireUser(middleware.AllowMethods([]string{"POST"}, http.HandlerFunc(handlers.CreateWorkoutHandler))))
+	mux.Handle("/v2/workouts/list", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetWorkoutPageHandler)))
 	mux.Handle("/workouts/comparison", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetWorkoutComparisonHandler)))
+	mux.Handle("/v2/workouts/comparison", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetWorkoutComparisonV2Handler)))
 
 	// SESSION
 	mux.Handle("/session/data", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetSessionHandler)))
 	mux.Handle("/session/create", middleware.RequireUser(middleware.AllowMethods([]string{"POST"}, http.HandlerFunc(handlers.CreateSessionHandler))))
 	mux.Handle("/session/update", middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.UpdateSessionHandler)))
+	mux.Handle("/session/notes", middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.UpdateSessionNotesHandler)))
+	mux.Handle("/session/substitutes", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetSessionSubstitutesHandler)))
+	mux.Handle("/session/substitute", middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.SubstituteSessionExerciseHandler)))
+	mux.Handle("/session/pause", middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.PauseSessionHandler)))
+	mux.Handle("/session/resume", middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.ResumeSessionHandler)))
+	mux.Handle("/session/abandon", middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.AbandonSessionHandler)))
 	mux.Handle("/session/delete", middleware.AllowMethods([]string{"DELETE"}, http.HandlerFunc(handlers.DeleteSessionHandler)))
 
 	// HISTORY
@@ -50,6 +71,34 @@ func RegisterRoutes(mux *http.ServeMux) {
 	mux.Handle("/history/data", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetExerciseHistoryHandler)))
 	mux.Handle("/history/update", middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.UpdateExerciseHistoryHandler)))
 
+	// EQUIPMENT
+	mux.Handle("/equipment/data", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetEquipmentHandler)))
+	mux.Handle("/equipment/update", middleware.RequireUser(middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.UpdateEquipmentHandler))))
+	mux.Handle("/equipment/plates", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetPlateLoadingHandler)))
+
+	// MEASUREMENTS
+	mux.Handle("/measurements/list", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetMeasurementListHandler)))
+	mux.Handle("/measurements/trend", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetBodyTrendHandler)))
+	mux.Handle("/measurements/create", middleware.RequireUser(middleware.AllowMethods([]string{"POST"}, http.HandlerFunc(handlers.CreateMeasurementHandler))))
+	mux.Handle("/measurements/update", middleware.RequireUser(middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.UpdateMeasurementHandler))))
+	mux.Handle("/measurements/delete", middleware.RequireUser(middleware.AllowMethods([]string{"DELETE"}, http.HandlerFunc(handlers.DeleteMeasurementHandler))))
+
+	// GOALS
+	mux.Handle("/goals/list", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetGoalListHandler)))
+	mux.Handle("/goals/create", middleware.RequireUser(middleware.AllowMethods([]string{"POST"}, http.HandlerFunc(handlers.CreateGoalHandler))))
+	mux.Handle("/goals/update", middleware.RequireUser(middleware.AllowMethods([]string{"PATCH"}, http.HandlerFunc(handlers.UpdateGoalHandler))))
+	mux.Handle("/goals/delete", middleware.RequireUser(middleware.AllowMethods([]string{"DELETE"}, http.HandlerFunc(handlers.DeleteGoalHandler))))
+
+	// ANALYTICS
+	mux.Handle("/analytics/muscle-volume", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetMuscleVolumeHandler)))
+	mux.Handle("/analytics/strength", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetStrengthScoresHandler)))
+	mux.Handle("/analytics/training-load", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetTrainingLoadHandler)))
+	mux.Handle("/analytics/plateaus", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetPlateausHandler)))
+	mux.Handle("/analytics/series", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetAnalyticsSeriesHandler)))
+
+	// REPORTS
+	mux.Handle("/reports/summary", middleware.AllowMethods([]string{"GET"}, http.HandlerFunc(handlers.GetSummaryReportHandler)))
+
 	// CARDIO removed
 
 	// AUTH
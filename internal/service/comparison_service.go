@@ -0,0 +1,250 @@
+package service
+
+import (
+	"errors"
+	"sort"
+
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrNotEnoughWorkouts = errors.New("not enough workouts to compare")
+
+// Comparison statuses for an exercise across the compared workouts.
+const (
+	ComparisonCompared     = "compared"
+	ComparisonAdded        = "added"
+	ComparisonRemoved      = "removed"
+	ComparisonIntermittent = "intermittent"
+)
+
+type ComparedWorkout struct {
+	ID          primitive.ObjectID `json:"id"`
+	WorkoutDate primitive.DateTime `json:"workout_date"`
+}
+
+// ExerciseSnapshot is an exercise's performance in one workout. Present is
+// false when the exercise wasn't done in that workout.
+type ExerciseSnapshot struct {
+	WorkoutID   primitive.ObjectID  `json:"workout_id"`
+	Present     bool                `json:"present"`
+	Equipment   string              `json:"equipment,omitempty"`
+	MaxWeight   float64             `json:"max_weight"`
+	TotalReps   int                 `json:"reps"`
+	TotalVolume float64             `json:"volume"`
+	Sets        []models.WorkoutSet `json:"sets,omitempty"`
+}
+
+type SetDelta struct {
+	Set          int     `json:"set"`
+	RepsChange   int     `json:"reps_change"`
+	WeightChange float64 `json:"weight_change"`
+	Added        bool    `json:"added,omitempty"`
+	Removed      bool    `json:"removed,omitempty"`
+}
+
+// ExerciseComparison compares one exercise/variation across the workouts.
+// The top-level metrics are the latest values and the changes are measured
+// against the first workout in the comparison.
+type ExerciseComparison struct {
+	ExerciseID   primitive.ObjectID `json:"exercise_id"`
+	ExerciseName string             `json:"exercise_name"`
+	Variation    string             `json:"variation"`
+	Status       string             `json:"status"`
+	MaxWeight    float64            `json:"max_weight"`
+	TotalReps    int                `json:"reps"`
+	TotalVolume  float64            `json:"volume"`
+	WeightChange float64            `json:"weight_change"`
+	RepsChange   int                `json:"reps_change"`
+	VolumeChange float64            `json:"volume_change"`
+	SetDeltas    []SetDelta         `json:"set_deltas"`
+	Sessions     []ExerciseSnapshot `json:"sessions"`
+}
+
+type WorkoutComparison struct {
+	Workouts  []ComparedWorkout    `json:"workouts"`
+	Exercises []ExerciseComparison `json:"exercises"`
+	Unit      string               `json:"unit"`
+}
+
+// CompareWorkouts compares the given workouts of a user. Each workout's
+// per-exercise totals are computed by the database.
+func CompareWorkouts(userID primitive.ObjectID, workoutIDs []primitive.ObjectID) (*WorkoutComparison, error) {
+	workouts, err := database.GetWorkoutTotals(userID, workoutIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(workouts) < 2 {
+		return nil, ErrNotEnoughWorkouts
+	}
+
+	return compare(userID, workouts)
+}
+
+// CompareRecentWorkouts compares the last n workouts of a routine.
+func CompareRecentWorkouts(userID, routineID primitive.ObjectID, n int) (*WorkoutComparison, error) {
+	workouts, err := database.GetRecentWorkoutTotals(userID, routineID, int64(n))
+	if err != nil {
+		return nil, err
+	}
+	if len(workouts) < 2 {
+		return nil, ErrNotEnoughWorkouts
+	}
+
+	return compare(userID, workouts)
+}
+
+type comparisonKey struct {
+	ExerciseID primitive.ObjectID
+	Variation  string
+}
+
+// compare builds the comparison for workouts ordered oldest first. Exercises
+// follow the latest workout's routine order, then order of first appearance.
+func compare(userID primitive.ObjectID, workouts []database.WorkoutTotals) (*WorkoutComparison, error) {
+	latest := workouts[len(workouts)-1]
+
+	var order []comparisonKey
+	snapshots := make(map[comparisonKey][]ExerciseSnapshot)
+	var exerciseIDs []primitive.ObjectID
+
+	for i, workout := range workouts {
+		for _, exercise := range workout.Exercises {
+			key := comparisonKey{ExerciseID: exercise.ExerciseID, Variation: exercise.Variation}
+			if _, ok := snapshots[key]; !ok {
+				snapshots[key] = make([]ExerciseSnapshot, len(workouts))
+				order = append(order, key)
+				exerciseIDs = append(exerciseIDs, exercise.ExerciseID)
+			}
+			snapshots[key][i] = ExerciseSnapshot{
+				Present:     true,
+				Equipment:   exercise.Equipment,
+				MaxWeight:   exercise.MaxWeight,
+				TotalReps:   exercise.Reps,
+				TotalVolume: exercise.Volume,
+				Sets:        exercise.Sets,
+			}
+		}
+	}
+
+	order = sortByRoutine(userID, latest.RoutineID, order)
+
+	names, err := database.GetExerciseNames(exerciseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WorkoutComparison{
+		Workouts:  make([]ComparedWorkout, 0, len(workouts)),
+		Exercises: make([]ExerciseComparison, 0, len(order)),
+	}
+	for _, workout := range workouts {
+		result.Workouts = append(result.Workouts, ComparedWorkout{ID: workout.ID, WorkoutDate: workout.WorkoutDate})
+	}
+
+	for _, key := range order {
+		sessions := snapshots[key]
+		for i := range sessions {
+			sessions[i].WorkoutID = workouts[i].ID
+		}
+
+		first, last := sessions[0], sessions[len(sessions)-1]
+
+		name, ok := names[key.ExerciseID]
+		if !ok {
+			name = "unknown"
+		}
+
+		comparison := ExerciseComparison{
+			ExerciseID:   key.ExerciseID,
+			ExerciseName: name,
+			Variation:    key.Variation,
+			MaxWeight:    last.MaxWeight,
+			TotalReps:    last.TotalReps,
+			TotalVolume:  last.TotalVolume,
+			WeightChange: last.MaxWeight - first.MaxWeight,
+			RepsChange:   last.TotalReps - first.TotalReps,
+			VolumeChange: last.TotalVolume - first.TotalVolume,
+			SetDeltas:    setDeltas(first.Sets, last.Sets),
+			Sessions:     sessions,
+		}
+
+		switch {
+		case first.Present && last.Present:
+			comparison.Status = ComparisonCompared
+		case last.Present:
+			comparison.Status = ComparisonAdded
+		case first.Present:
+			comparison.Status = ComparisonRemoved
+		default:
+			comparison.Status = ComparisonIntermittent
+		}
+
+		result.Exercises = append(result.Exercises, comparison)
+	}
+
+	return result, nil
+}
+
+func setDeltas(before, after []models.WorkoutSet) []SetDelta {
+	n := len(before)
+	if len(after) > n {
+		n = len(after)
+	}
+
+	deltas := make([]SetDelta, 0, n)
+	for i := 0; i < n; i++ {
+		delta := SetDelta{Set: i + 1}
+		switch {
+		case i >= len(before):
+			delta.Added = true
+			delta.RepsChange = after[i].Reps
+			delta.WeightChange = after[i].Weight
+		case i >= len(after):
+			delta.Removed = true
+			delta.RepsChange = -before[i].Reps
+			delta.WeightChange = -before[i].Weight
+		default:
+			delta.RepsChange = after[i].Reps - before[i].Reps
+			delta.WeightChange = after[i].Weight - before[i].Weight
+		}
+		deltas = append(deltas, delta)
+	}
+	return deltas
+}
+
+// sortByRoutine orders keys by their exercise's position in the routine,
+// keeping first-appearance order for ties and exercises not in the routine.
+func sortByRoutine(userID, routineID primitive.ObjectID, keys []comparisonKey) []comparisonKey {
+	if routineID.IsZero() {
+		return keys
+	}
+	routine, err := database.GetRoutineData(userID, routineID)
+	if err != nil {
+		return keys
+	}
+
+	position := make(map[primitive.ObjectID]int, len(routine.Exercises))
+	for i, ex := range routine.Exercises {
+		if _, ok := position[ex.ExerciseID]; !ok {
+			position[ex.ExerciseID] = i
+		}
+	}
+
+	var inRoutine, rest []comparisonKey
+	for _, key := range keys {
+		if _, ok := position[key.ExerciseID]; ok {
+			inRoutine = append(inRoutine, key)
+		} else {
+			rest = append(rest, key)
+		}
+	}
+
+	sort.SliceStable(inRoutine, func(i, j int) bool {
+		return position[inRoutine[i].ExerciseID] < position[inRoutine[j].ExerciseID]
+	})
+
+	return append(inRoutine, rest...)
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidTaxonomy = errors.New("invalid exercise taxonomy")
+	ErrForbidden       = errors.New("not allowed to modify this exercise")
+	ErrExerciseInUse   = errors.New("exercise is still referenced")
+)
+
+// Catalog sort orders.
+const (
+	SortRelevance = database.SortRelevance
+	SortName      = database.SortName
+	SortNameDesc  = database.SortNameDesc
+	SortCategory  = database.SortCategory
+)
+
+// ExerciseSearch describes a catalog query. Query is matched against names,
+// aliases and variations in every language: exact, prefix, substring and fuzzy
+// (small typo) matches are all accepted and ranked in that order. Results are
+// localized to the first of Locales each exercise has a translation for.
+type ExerciseSearch struct {
+	Query   string
+	Filter  database.ExerciseFilter
+	Sort    string
+	Limit   int
+	Offset  int
+	Locales []string
+}
+
+type FacetCount = database.FacetCount
+
+type ExerciseFacets struct {
+	Category  []FacetCount `json:"category"`
+	Equipment []FacetCount `json:"equipment"`
+}
+
+type ExerciseSearchResult struct {
+	Exercises []models.Exercise `json:"exercises"`
+	Total     int               `json:"total"`
+	Facets    ExerciseFacets    `json:"facets"`
+}
+
+// SearchCatalog runs a catalog search. Facet counts honour every filter except
+// the one being counted, so clients can show how many results picking another
+// category or piece of equipment would give; each facet value is the
+// canonical one to filter by, labelled as the localized exercises show it.
+// Filtering, ranking, paging and counting happen in the database; only a
+// query with no substring match falls back to typo-tolerant matching here,
+// whose hits are then searched like any other.
+func SearchCatalog(search ExerciseSearch) (*ExerciseSearchResult, error) {
+	sortOrder := search.Sort
+	if sortOrder == "" {
+		sortOrder = SortName
+		if search.Query != "" {
+			sortOrder = SortRelevance
+		}
+	}
+
+	catalog := database.CatalogSearch{
+		Filter:  search.Filter,
+		Text:    search.Query,
+		Sort:    sortOrder,
+		Offset:  search.Offset,
+		Limit:   search.Limit,
+		Locales: labelLocales(search.Locales),
+	}
+	page, err := database.SearchCatalog(catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.Total == 0 && search.Query != "" {
+		ids, err := fuzzyMatches(search)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			catalog.Text = ""
+			catalog.IDs = ids
+			if page, err = database.SearchCatalog(catalog); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := &ExerciseSearchResult{
+		Exercises: page.Exercises,
+		Total:     page.Total,
+		Facets: ExerciseFacets{
+			Category:  page.Categories,
+			Equipment: page.Equipment,
+		},
+	}
+	if len(search.Locales) > 0 {
+		for i, exercise := range result.Exercises {
+			result.Exercises[i] = LocalizeExercise(exercise, search.Locales)
+		}
+	}
+	return result, nil
+}
+
+// labelLocales are the requested locales facet values get translated to:
+// the supported ones before the default locale, which needs no translation.
+func labelLocales(locales []string) []string {
+	var labels []string
+	for _, locale := range locales {
+		if locale == config.DefaultLocale {
+			break
+		}
+		if slices.Contains(config.AppConfig.SupportedLocales, locale) {
+			labels = append(labels, locale)
+		}
+	}
+	return labels
+}
+
+// fuzzyMatches returns the exercises, category and equipment filters aside,
+// that the query matches with a small typo.
+func fuzzyMatches(search ExerciseSearch) ([]primitive.ObjectID, error) {
+	filter := search.Filter
+	filter.Category = ""
+	filter.Equipment = nil
+
+	candidates, err := database.SearchExercises(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []primitive.ObjectID
+	for _, exercise := range candidates {
+		if matchExercise(search.Query, exercise, search.Locales) > 0 {
+			ids = append(ids, exercise.ID)
+		}
+	}
+	return ids, nil
+}
+
+// matchExercise scores how well query matches the exercise; 0 means no match.
+// Names in the requested locales count as much as the English name.
+func matchExercise(query string, exercise models.Exercise, locales []string) int {
+	best := matchText(query, exercise.Name)
+	for _, a := range exercise.Aliases {
+		// Alias hits rank just below name hits of the same kind
+		if score := matchText(query, a) - 5; score > best {
+			best = score
+		}
+	}
+	for locale, t := range exercise.Translations {
+		penalty := 5
+		if slices.Contains(locales, locale) {
+			penalty = 0
+		}
+		if score := matchText(query, t.Name) - penalty; score > best {
+			best = score
+		}
+		for _, a := range t.Aliases {
+			if score := matchText(query, a) - 5 - penalty; score > best {
+				best = score
+			}
+		}
+	}
+	for _, v := range exercise.Variations {
+		// Variation hits rank below name hits of the same kind
+		if score := matchText(query, v) / 2; score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+func matchText(query, text string) int {
+	q := strings.ToLower(strings.TrimSpace(query))
+	t := strings.ToLower(text)
+	if q == "" || t == "" {
+		return 0
+	}
+
+	switch {
+	case q == t:
+		return 100
+	case strings.HasPrefix(t, q):
+		return 80
+	}
+
+	words := strings.FieldsFunc(t, isWordSeparator)
+	for _, w := range words {
+		if strings.HasPrefix(w, q) {
+			return 60
+		}
+	}
+	if strings.Contains(t, q) {
+		return 40
+	}
+
+	// Fuzzy: every query word must be within a small edit distance of some
+	// word (or word prefix) in the text.
+	for _, qw := range strings.FieldsFunc(q, isWordSeparator) {
+		found := false
+		for _, w := range words {
+			if withinTypo(qw, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+	}
+	return 20
+}
+
+// withinTypo allows one edit for short words and two for longer ones,
+// comparing against the whole word and its same-length prefix.
+func withinTypo(query, word string) bool {
+	allowed := 1
+	if len(query) > 5 {
+		allowed = 2
+	}
+	if len(query) < 3 {
+		return false
+	}
+	if levenshtein(query, word) <= allowed {
+		return true
+	}
+	if len(word) > len(query) && levenshtein(query, word[:len(query)]) <= allowed {
+		return true
+	}
+	return false
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func isWordSeparator(r rune) bool {
+	return r == ' ' || r == '-' || r == '/' || r == '(' || r == ')' || r == ','
+}
+
+// ValidateTaxonomy checks muscle groups, movement pattern, force and mechanic
+// against the known values. Empty fields are allowed.
+func ValidateTaxonomy(exercise models.Exercise) error {
+	for _, m := range append(slices.Clone(exercise.PrimaryMuscles), exercise.SecondaryMuscles...) {
+		if !slices.Contains(models.MuscleGroups, m) {
+			return ErrInvalidTaxonomy
+		}
+	}
+	if exercise.MovementPattern != "" && !slices.Contains(models.MovementPatterns, exercise.MovementPattern) {
+		return ErrInvalidTaxonomy
+	}
+	if exercise.Force != "" && !slices.Contains(models.ForceTypes, exercise.Force) {
+		return ErrInvalidTaxonomy
+	}
+	if exercise.Mechanic != "" && !slices.Contains(models.Mechanics, exercise.Mechanic) {
+		return ErrInvalidTaxonomy
+	}
+	return nil
+}
+
+// IsAdmin reports whether the user may administer the global catalog.
+func IsAdmin(userID primitive.ObjectID) bool {
+	user, err := database.GetUserByID(userID)
+	return err == nil && user.ClearanceLevel >= models.AdminClearance
+}
+
+// CanEditExercise allows owners to edit their custom exercises and admins to
+// edit anything.
+func CanEditExercise(userID primitive.ObjectID, exercise models.Exercise) bool {
+	if !exercise.OwnerID.IsZero() && exercise.OwnerID == userID {
+		return true
+	}
+	return IsAdmin(userID)
+}
+
+// CanViewExercise reports whether the user may see the exercise: catalog
+// exercises are public, private ones only visible to those who can edit them.
+func CanViewExercise(userID primitive.ObjectID, exercise models.Exercise) bool {
+	return exercise.OwnerID.IsZero() || CanEditExercise(userID, exercise)
+}
+
+// ExerciseReferenceReport shows what depends on an exercise and whether it can
+// be hard-deleted.
+type ExerciseReferenceReport struct {
+	ExerciseID primitive.ObjectID          `json:"exercise_id"`
+	Name       string                      `json:"name"`
+	Archived   bool                        `json:"archived"`
+	References database.ExerciseReferences `json:"references"`
+	Deletable  bool                        `json:"deletable"`
+}
+
+// ExerciseReferenceReportFor builds the reference report for an exercise. The
+// warm-up and cool-down exercises are never deletable since generated sessions
+// always use them.
+func ExerciseReferenceReportFor(exercise models.Exercise) (*ExerciseReferenceReport, error) {
+	refs, err := database.CountExerciseReferences(exercise.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExerciseReferenceReport{
+		ExerciseID: exercise.ID,
+		Name:       exercise.Name,
+		Archived:   exercise.Archived,
+		References: refs,
+		Deletable:  refs.Total() == 0 && !isStaticExercise(exercise.ID),
+	}, nil
+}
+
+// SetExerciseArchived archives or restores an exercise. Archived exercises are
+// left out of catalog search but still resolve by ID, so existing routines,
+// workouts and history keep working.
+func SetExerciseArchived(exerciseID primitive.ObjectID, archived bool) error {
+	return database.UpdateExercise(exerciseID, bson.M{"archived": archived})
+}
+
+// DeleteUnreferencedExercise hard-deletes an exercise nothing points at. When
+// it is still referenced, the report is returned with ErrExerciseInUse.
+func DeleteUnreferencedExercise(exercise models.Exercise) (*ExerciseReferenceReport, error) {
+	report, err := ExerciseReferenceReportFor(exercise)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Deletable {
+		return report, ErrExerciseInUse
+	}
+
+	if err := database.DeleteExercise(exercise.ID); err != nil {
+		return nil, err
+	}
+	deleteMediaBlobs(exercise)
+
+	return report, nil
+}
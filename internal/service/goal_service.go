@@ -0,0 +1,356 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+	"fitness-tracker/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidGoal = errors.New("invalid goal")
+
+const (
+	// goalTrendWindow is how much recent data projections are fitted on.
+	goalTrendWindow = 56 * 24 * time.Hour
+	// goalE1RMWindow is how far back the current e1RM of a goal looks.
+	goalE1RMWindow = 90 * 24 * time.Hour
+	// goalProjectionLimit caps projections; slower trends give none.
+	goalProjectionLimit = 2 * 365 * 24 * time.Hour
+)
+
+// NewGoal validates a goal sent by the user, converting weight targets from
+// their unit, and measures where they start from.
+func NewGoal(userID primitive.ObjectID, dto models.GoalDTO, unit string) (models.Goal, error) {
+	now := UserNow(userID)
+	goal := models.Goal{
+		UserID:    userID,
+		Type:      dto.Type,
+		Status:    models.GoalActive,
+		CreatedAt: primitive.NewDateTimeFromTime(now),
+	}
+
+	if !slices.Contains(models.GoalTypes, dto.Type) || dto.Target <= 0 {
+		return goal, ErrInvalidGoal
+	}
+
+	if dto.Type == models.GoalExerciseE1RM {
+		exercises, err := lookupExercises(userID, []string{dto.ExerciseID})
+		if err != nil {
+			return goal, ErrInvalidGoal
+		}
+		goal.ExerciseID = exercises[0].ID
+	}
+
+	if err := setGoalTarget(&goal, dto.Target, unit); err != nil {
+		return goal, err
+	}
+	if err := setGoalDeadline(&goal, dto.Deadline, now); err != nil {
+		return goal, err
+	}
+
+	current, _, err := measureGoal(goal, now)
+	if err != nil {
+		return goal, err
+	}
+	goal.Start = current
+
+	return goal, EvaluateGoal(&goal, now)
+}
+
+// UpdateGoalTarget changes the target and deadline of a goal, re-opening it
+// when the new target is not met yet. A zero target or empty deadline keeps
+// the current one.
+func UpdateGoalTarget(goal *models.Goal, target float64, deadline, unit string) error {
+	now := UserNow(goal.UserID)
+	if target < 0 {
+		return ErrInvalidGoal
+	}
+	if target > 0 {
+		if err := setGoalTarget(goal, target, unit); err != nil {
+			return err
+		}
+	}
+	if err := setGoalDeadline(goal, deadline, now); err != nil {
+		return err
+	}
+
+	goal.Status = models.GoalActive
+	goal.AchievedAt = 0
+	return EvaluateGoal(goal, now)
+}
+
+func setGoalTarget(goal *models.Goal, target float64, unit string) error {
+	switch goal.Type {
+	case models.GoalWorkoutsPerWeek:
+		if target > 14 {
+			return ErrInvalidGoal
+		}
+		goal.Target = math.Round(target)
+	default:
+		goal.Target = units.ToKg(target, unit)
+	}
+	return nil
+}
+
+// setGoalDeadline takes a plain date deadline as the end of that day in now's
+// time zone.
+func setGoalDeadline(goal *models.Goal, deadline string, now time.Time) error {
+	if deadline != "" {
+		date, err := utils.ParseDateParamIn(deadline, true, now.Location())
+		if err != nil || !date.After(now) {
+			return ErrInvalidGoal
+		}
+		goal.Deadline = primitive.NewDateTimeFromTime(date)
+	}
+	return nil
+}
+
+// EvaluateGoals refreshes every active goal of the user. It runs after each
+// saved workout and body log. Weeks and months follow the user's time zone.
+// A goal that fails to evaluate is logged and skipped so the others still
+// refresh.
+func EvaluateGoals(userID primitive.ObjectID) error {
+	goals, err := database.GetUserGoals(userID, models.GoalActive)
+	if err != nil {
+		return err
+	}
+
+	now := UserNow(userID)
+	for i := range goals {
+		goal := &goals[i]
+		if err := EvaluateGoal(goal, now); err != nil {
+			log.Printf("Failed to evaluate goal %s: %v", goal.ID.Hex(), err)
+			continue
+		}
+		err := database.UpdateGoal(goal.ID, bson.M{
+			"current":     goal.Current,
+			"progress":    goal.Progress,
+			"status":      goal.Status,
+			"projectedAt": goal.ProjectedAt,
+			"achievedAt":  goal.AchievedAt,
+			"evaluatedAt": goal.EvaluatedAt,
+		})
+		if err != nil {
+			log.Printf("Failed to save goal %s: %v", goal.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// EvaluateGoal measures an active goal and updates its progress, projected
+// completion date and status in place. Achieved and missed goals keep their
+// status.
+func EvaluateGoal(goal *models.Goal, now time.Time) error {
+	if goal.Status != models.GoalActive {
+		return nil
+	}
+
+	current, samples, err := measureGoal(*goal, now)
+	if err != nil {
+		return err
+	}
+	goal.Current = current
+	goal.EvaluatedAt = primitive.NewDateTimeFromTime(now)
+	goal.Progress = goalProgress(*goal)
+	goal.ProjectedAt = 0
+
+	switch {
+	case goal.Progress >= 1:
+		goal.Status = models.GoalAchieved
+		goal.AchievedAt = primitive.NewDateTimeFromTime(now)
+	case goal.Deadline != 0 && now.After(goal.Deadline.Time()):
+		goal.Status = models.GoalMissed
+	default:
+		if projected, ok := projectGoal(*goal, samples, now); ok {
+			goal.ProjectedAt = primitive.NewDateTimeFromTime(projected)
+		}
+	}
+	return nil
+}
+
+// goalProgress is how far the goal has come, from 0 to 1. Strength and
+// bodyweight goals count from the starting value; the weekly and monthly
+// goals start from zero every period. Strength goals are only met by lifting
+// at least the target.
+func goalProgress(goal models.Goal) float64 {
+	var progress float64
+	switch goal.Type {
+	case models.GoalExerciseE1RM:
+		if goal.Current == 0 {
+			return 0
+		}
+		if goal.Current >= goal.Target {
+			progress = 1
+		} else if goal.Target > goal.Start {
+			progress = (goal.Current - goal.Start) / (goal.Target - goal.Start)
+		}
+	case models.GoalBodyweight:
+		if goal.Current == 0 {
+			return 0
+		}
+		// Bodyweight goals can go either way
+		direction := goal.Target - goal.Start
+		if (direction >= 0 && goal.Current >= goal.Target) || (direction < 0 && goal.Current <= goal.Target) {
+			progress = 1
+		} else {
+			progress = (goal.Current - goal.Start) / direction
+		}
+	default:
+		progress = goal.Current / goal.Target
+	}
+	return units.Round(math.Min(math.Max(progress, 0), 1), 0.001)
+}
+
+// projectGoal extrapolates the samples' trend to the date the target is
+// reached. Goals moving the wrong way, or too slowly, get no projection.
+func projectGoal(goal models.Goal, samples []trendSample, now time.Time) (time.Time, bool) {
+	slope, intercept, ok := linearFit(samples)
+	if !ok || slope == 0 {
+		return time.Time{}, false
+	}
+
+	origin := samples[0].date
+	days := (goal.Target - intercept) / slope
+	if days < 0 {
+		return time.Time{}, false
+	}
+
+	projected := origin.Add(time.Duration(days * 24 * float64(time.Hour)))
+	if projected.Before(now) {
+		projected = now
+	}
+	if projected.Sub(now) > goalProjectionLimit {
+		return time.Time{}, false
+	}
+	// Monthly volume starts over with the next month
+	if goal.Type == models.GoalMonthlyVolume && !projected.Before(monthStart(now).AddDate(0, 1, 0)) {
+		return time.Time{}, false
+	}
+	return projected, true
+}
+
+// measureGoal returns the goal's current value and the recent samples its
+// trend is fitted on.
+func measureGoal(goal models.Goal, now time.Time) (float64, []trendSample, error) {
+	switch goal.Type {
+	case models.GoalExerciseE1RM:
+		return measureE1RM(goal, now)
+	case models.GoalBodyweight:
+		return measureBodyweight(goal, now)
+	case models.GoalWorkoutsPerWeek:
+		return measureWorkouts(goal, now)
+	case models.GoalMonthlyVolume:
+		return measureMonthlyVolume(goal, now)
+	}
+	return 0, nil, ErrInvalidGoal
+}
+
+// measureE1RM takes the best estimated one-rep max of the last 90 days, and
+// each workout's best of the trend window as samples.
+func measureE1RM(goal models.Goal, now time.Time) (float64, []trendSample, error) {
+	workouts, err := database.GetUserWorkoutsInRange(goal.UserID, now.Add(-goalE1RMWindow), now.Add(time.Second))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	current := 0.0
+	var samples []trendSample
+	for _, workout := range workouts {
+		best := 0.0
+		for _, exercise := range workout.Exercises {
+			if exercise.ExerciseID != goal.ExerciseID {
+				continue
+			}
+			for _, s := range exercise.Sets {
+				best = math.Max(best, EstimateOneRepMax(s.Weight, s.Reps))
+			}
+		}
+		if best == 0 {
+			continue
+		}
+		current = math.Max(current, best)
+		if date := workout.WorkoutDate.Time(); date.After(now.Add(-goalTrendWindow)) {
+			samples = append(samples, trendSample{date, best})
+		}
+	}
+	return current, samples, nil
+}
+
+func measureBodyweight(goal models.Goal, now time.Time) (float64, []trendSample, error) {
+	entries, err := database.GetUserMeasurements(goal.UserID, now.Add(-goalTrendWindow), time.Time{})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var samples []trendSample
+	for _, entry := range entries {
+		if entry.Bodyweight > 0 {
+			samples = append(samples, trendSample{entry.Date.Time(), entry.Bodyweight})
+		}
+	}
+	return LatestBodyweight(goal.UserID), samples, nil
+}
+
+// measureWorkouts counts the workouts of the last seven days, sampling the
+// same rolling count at the end of every week of the trend window.
+func measureWorkouts(goal models.Goal, now time.Time) (float64, []trendSample, error) {
+	week := 7 * 24 * time.Hour
+	workouts, err := database.GetUserWorkoutsInRange(goal.UserID, now.Add(-goalTrendWindow-week), now.Add(time.Second))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	countBefore := func(end time.Time) float64 {
+		n := 0.0
+		for _, workout := range workouts {
+			date := workout.WorkoutDate.Time()
+			if date.After(end.Add(-week)) && !date.After(end) {
+				n++
+			}
+		}
+		return n
+	}
+
+	var samples []trendSample
+	for end := now.Add(-goalTrendWindow); !end.After(now); end = end.Add(week) {
+		samples = append(samples, trendSample{end, countBefore(end)})
+	}
+	return countBefore(now), samples, nil
+}
+
+// measureMonthlyVolume totals the volume of the current month, with the
+// running total after every workout as samples.
+func measureMonthlyVolume(goal models.Goal, now time.Time) (float64, []trendSample, error) {
+	start := monthStart(now)
+	workouts, err := database.GetUserWorkoutsInRange(goal.UserID, start, now.Add(time.Second))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	catalog, err := exercisesForWorkouts(workouts)
+	if err != nil {
+		return 0, nil, err
+	}
+	bodyweights := LoadBodyweightLog(goal.UserID)
+
+	total := 0.0
+	samples := []trendSample{{start, 0}}
+	for _, workout := range workouts {
+		total += workoutVolume(workout, catalog, bodyweights)
+		samples = append(samples, trendSample{workout.WorkoutDate.Time(), total})
+	}
+	return total, samples, nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
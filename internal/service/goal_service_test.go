@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"fitness-tracker/internal/models"
+)
+
+func TestGoalProgress(t *testing.T) {
+	tests := []struct {
+		name                   string
+		kind                   string
+		start, current, target float64
+		want                   float64
+	}{
+		{"e1rm halfway", models.GoalExerciseE1RM, 100, 110, 120, 0.5},
+		{"e1rm met", models.GoalExerciseE1RM, 100, 125, 120, 1},
+		{"e1rm below the start", models.GoalExerciseE1RM, 100, 90, 120, 0},
+		{"e1rm not measured", models.GoalExerciseE1RM, 100, 0, 120, 0},
+		{"e1rm target under the start", models.GoalExerciseE1RM, 130, 115, 120, 0},
+		{"e1rm target under the start met", models.GoalExerciseE1RM, 130, 120, 120, 1},
+		{"losing weight", models.GoalBodyweight, 90, 85, 80, 0.5},
+		{"losing weight met", models.GoalBodyweight, 90, 79, 80, 1},
+		{"losing weight gained", models.GoalBodyweight, 90, 92, 80, 0},
+		{"gaining weight", models.GoalBodyweight, 60, 63, 66, 0.5},
+		{"gaining weight met", models.GoalBodyweight, 60, 66, 66, 1},
+		{"bodyweight not measured", models.GoalBodyweight, 90, 0, 80, 0},
+		{"workouts per week", models.GoalWorkoutsPerWeek, 0, 2, 3, 0.667},
+		{"workouts per week exceeded", models.GoalWorkoutsPerWeek, 0, 5, 3, 1},
+		{"monthly volume", models.GoalMonthlyVolume, 0, 25000, 100000, 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := models.Goal{Type: tt.kind, Start: tt.start, Current: tt.current, Target: tt.target}
+			if got := goalProgress(goal); got != tt.want {
+				t.Errorf("goalProgress() = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectGoal(t *testing.T) {
+	origin := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return origin.AddDate(0, 0, n) }
+	rising := []trendSample{{day(0), 100}, {day(10), 110}}
+
+	tests := []struct {
+		name    string
+		kind    string
+		target  float64
+		samples []trendSample
+		now     time.Time
+		want    time.Time
+		ok      bool
+	}{
+		{"on trend", models.GoalExerciseE1RM, 120, rising, day(10), day(20), true},
+		{"already passed", models.GoalExerciseE1RM, 105, rising, day(10), day(10), true},
+		{"wrong way", models.GoalBodyweight, 90, rising, day(10), time.Time{}, false},
+		{"flat", models.GoalBodyweight, 90, []trendSample{{day(0), 100}, {day(10), 100}}, day(10), time.Time{}, false},
+		{"too far out", models.GoalExerciseE1RM, 1000, rising, day(10), time.Time{}, false},
+		{"one sample", models.GoalExerciseE1RM, 120, rising[:1], day(10), time.Time{}, false},
+		{"volume within the month", models.GoalMonthlyVolume, 120, rising, day(10), day(20), true},
+		{"volume after the month", models.GoalMonthlyVolume, 150, rising, day(10), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := models.Goal{Type: tt.kind, Target: tt.target}
+			got, ok := projectGoal(goal, tt.samples, tt.now)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("projectGoal() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
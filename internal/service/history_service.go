@@ -39,6 +39,10 @@ func UpdateExerciseHistory(userID, workoutID string) error {
 		cooldownValid = true
 	}
 
+	// Entries are dated by the lifter's clock so they fall on the workout's
+	// day rather than the day it happened to be saved
+	date := primitive.NewDateTimeFromTime(WorkoutStart(workoutData, UserLocation(userObjID)))
+
 	for _, exercise := range workoutData.Exercises {
 		// Skip warm-up/cool-down only if IDs are valid
 		if (warmupValid && exercise.ExerciseID == warmupID) || (cooldownValid && exercise.ExerciseID == cooldownID) {
@@ -59,7 +63,7 @@ func UpdateExerciseHistory(userID, workoutID string) error {
 		}
 
 		exSets := models.ExerciseSets{
-			Date:        workoutData.WorkoutDate,
+			Date:        date,
 			Equipment:   exercise.Equipment,
 			Variation:   exercise.Variation,
 			WorkoutSets: exercise.Sets,
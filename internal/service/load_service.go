@@ -0,0 +1,250 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// How a workout's training load is measured: the volume lifted, or session
+// RPE (the perceived difficulty of WorkoutMetadata) times duration in
+// minutes, in arbitrary units.
+const (
+	LoadVolume = "volume"
+	LoadSRPE   = "srpe"
+)
+
+// How acute and chronic load are averaged: rolling means over the windows, or
+// exponentially weighted moving averages with the same spans.
+const (
+	ACWRRolling = "rolling"
+	ACWREWMA    = "ewma"
+)
+
+// Training load warnings.
+const (
+	WarningACWRSpike    = "acwr_spike"
+	WarningACWRLow      = "acwr_low"
+	WarningMonotonyHigh = "monotony_high"
+)
+
+const (
+	acuteDays   = 7
+	chronicDays = 28
+	// maxSRPEDuration ignores sessions left open for hours.
+	maxSRPEDuration = 4 * time.Hour
+)
+
+// TrainingLoadDay is one day's load with the acute and chronic averages and
+// the monotony and strain of the week ending that day. ACWR is only set once
+// a full chronic window of data exists.
+type TrainingLoadDay struct {
+	Date     time.Time `json:"date"`
+	Load     float64   `json:"load"`
+	Acute    float64   `json:"acute"`
+	Chronic  float64   `json:"chronic"`
+	ACWR     float64   `json:"acwr,omitempty"`
+	Monotony float64   `json:"monotony,omitempty"`
+	Strain   float64   `json:"strain,omitempty"`
+	Warnings []string  `json:"warnings,omitempty"`
+}
+
+// TrainingLoadReport is a daily training load series. Skipped counts
+// workouts that couldn't be measured, such as sRPE without a difficulty or
+// start time.
+type TrainingLoadReport struct {
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Method   string            `json:"method"`
+	Model    string            `json:"model"`
+	ACWRHigh float64           `json:"acwr_high"`
+	ACWRLow  float64           `json:"acwr_low"`
+	Days     []TrainingLoadDay `json:"days"`
+	Skipped  int               `json:"skipped"`
+	Unit     string            `json:"unit,omitempty"` // set for volume loads
+}
+
+// TrainingLoadFor computes the daily training load series for [from, to),
+// loading a chronic window of workouts before from so the first days have
+// their averages.
+func TrainingLoadFor(userID primitive.ObjectID, from, to time.Time, method, model string) (*TrainingLoadReport, error) {
+	from = dayStart(from)
+	lead := from.AddDate(0, 0, -chronicDays)
+
+	workouts, err := database.GetUserWorkoutsInRange(userID, lead, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TrainingLoadReport{
+		From:     from,
+		To:       to,
+		Method:   method,
+		Model:    model,
+		ACWRHigh: config.AppConfig.Analytics.ACWRHigh,
+		ACWRLow:  config.AppConfig.Analytics.ACWRLow,
+		Days:     []TrainingLoadDay{},
+	}
+
+	var catalog map[primitive.ObjectID]models.Exercise
+	var bodyweights BodyweightLog
+	if method == LoadVolume {
+		if catalog, err = exercisesForWorkouts(workouts); err != nil {
+			return nil, err
+		}
+		bodyweights = LoadBodyweightLog(userID)
+	}
+
+	// Daily loads from the start of the lead-in
+	var loads []float64
+	var dates []time.Time
+	for day := lead; day.Before(to); day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day)
+		loads = append(loads, 0)
+	}
+	firstWorkout := -1
+	for _, workout := range workouts {
+		// Rounded since days around a DST change aren't 24 hours long
+		i := int(math.Round(WorkoutDay(workout, from.Location()).Sub(lead).Hours() / 24))
+		if i < 0 || i >= len(loads) {
+			continue
+		}
+
+		var load float64
+		if method == LoadSRPE {
+			var ok bool
+			if load, ok = sessionRPELoad(workout); !ok {
+				report.Skipped++
+				continue
+			}
+		} else {
+			load = workoutVolume(workout, catalog, bodyweights)
+		}
+		loads[i] += load
+		if firstWorkout < 0 || i < firstWorkout {
+			firstWorkout = i
+		}
+	}
+
+	acute, chronic := rollingLoads(loads)
+	if model == ACWREWMA {
+		acute, chronic = ewmaLoads(loads)
+	}
+
+	for i := chronicDays; i < len(loads); i++ {
+		day := TrainingLoadDay{
+			Date:    dates[i],
+			Load:    loads[i],
+			Acute:   acute[i],
+			Chronic: chronic[i],
+		}
+
+		// ACWR needs a full chronic window since training began
+		if firstWorkout >= 0 && i-firstWorkout+1 >= chronicDays && chronic[i] > 0 {
+			day.ACWR = units.Round(acute[i]/chronic[i], 0.01)
+			switch {
+			case day.ACWR > report.ACWRHigh:
+				day.Warnings = append(day.Warnings, WarningACWRSpike)
+			case day.ACWR < report.ACWRLow:
+				day.Warnings = append(day.Warnings, WarningACWRLow)
+			}
+		}
+
+		day.Monotony, day.Strain = monotonyStrain(loads[i-acuteDays+1 : i+1])
+		if day.Monotony > config.AppConfig.Analytics.MonotonyHigh {
+			day.Warnings = append(day.Warnings, WarningMonotonyHigh)
+		}
+
+		report.Days = append(report.Days, day)
+	}
+
+	return report, nil
+}
+
+// sessionRPELoad is difficulty times the minutes from session start to
+// finish.
+func sessionRPELoad(workout models.FullWorkout) (float64, bool) {
+	if workout.Metadata == nil || workout.Metadata.Difficulty == 0 || workout.StartedAt == 0 {
+		return 0, false
+	}
+	duration := workout.WorkoutDate.Time().Sub(workout.StartedAt.Time())
+	if duration <= 0 || duration > maxSRPEDuration {
+		return 0, false
+	}
+	return float64(workout.Metadata.Difficulty) * duration.Minutes(), true
+}
+
+// rollingLoads returns the mean daily load of the acute and chronic windows
+// ending on every day.
+func rollingLoads(loads []float64) (acute, chronic []float64) {
+	acute = make([]float64, len(loads))
+	chronic = make([]float64, len(loads))
+	var acuteSum, chronicSum float64
+	for i, load := range loads {
+		acuteSum += load
+		chronicSum += load
+		if i >= acuteDays {
+			acuteSum -= loads[i-acuteDays]
+		}
+		if i >= chronicDays {
+			chronicSum -= loads[i-chronicDays]
+		}
+		acute[i] = acuteSum / acuteDays
+		chronic[i] = chronicSum / chronicDays
+	}
+	return
+}
+
+// ewmaLoads returns exponentially weighted averages of the daily loads with
+// decay 2/(N+1) for the acute and chronic spans.
+func ewmaLoads(loads []float64) (acute, chronic []float64) {
+	acute = make([]float64, len(loads))
+	chronic = make([]float64, len(loads))
+	acuteDecay := 2.0 / (acuteDays + 1)
+	chronicDecay := 2.0 / (chronicDays + 1)
+	var a, c float64
+	for i, load := range loads {
+		a = load*acuteDecay + a*(1-acuteDecay)
+		c = load*chronicDecay + c*(1-chronicDecay)
+		acute[i] = a
+		chronic[i] = c
+	}
+	return
+}
+
+// monotonyStrain is a week's mean daily load over its standard deviation,
+// and the week's total load times that. Both are zero for a week without
+// variation.
+func monotonyStrain(week []float64) (monotony, strain float64) {
+	mean, sd := meanStdDev(week)
+	if sd == 0 {
+		return 0, 0
+	}
+	monotony = units.Round(mean/sd, 0.01)
+	return monotony, mean * float64(len(week)) * monotony
+}
+
+func meanStdDev(values []float64) (mean, sd float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		sd += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sd / float64(len(values)))
+}
+
+func dayStart(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// repeat returns n days of the same load.
+func repeat(load float64, n int) []float64 {
+	loads := make([]float64, n)
+	for i := range loads {
+		loads[i] = load
+	}
+	return loads
+}
+
+func TestAcuteChronicRatio(t *testing.T) {
+	tests := []struct {
+		name  string
+		loads []float64
+		model string
+		acwr  float64
+	}{
+		{"steady rolling", repeat(100, chronicDays), ACWRRolling, 1},
+		{"spike rolling", append(repeat(0, chronicDays-acuteDays), repeat(100, acuteDays)...), ACWRRolling, 4},
+		{"taper rolling", append(repeat(100, chronicDays-acuteDays), repeat(0, acuteDays)...), ACWRRolling, 0},
+		{"steady ewma", repeat(100, 200), ACWREWMA, 1},
+		{"spike ewma", append(repeat(0, 100), repeat(100, acuteDays)...), ACWREWMA, 2.2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acute, chronic := rollingLoads(tt.loads)
+			if tt.model == ACWREWMA {
+				acute, chronic = ewmaLoads(tt.loads)
+			}
+			last := len(tt.loads) - 1
+			if got := acute[last] / chronic[last]; math.Abs(got-tt.acwr) > 0.01 {
+				t.Errorf("acute %g / chronic %g = %.3f, want %.2f", acute[last], chronic[last], got, tt.acwr)
+			}
+		})
+	}
+}
+
+func TestRollingLoadsLeadIn(t *testing.T) {
+	acute, chronic := rollingLoads([]float64{70, 0})
+	if acute[0] != 10 || chronic[0] != 2.5 || acute[1] != 10 || chronic[1] != 2.5 {
+		t.Errorf("rollingLoads() = %v, %v, want the lead-in averaged over full windows", acute, chronic)
+	}
+}
+
+func TestMonotonyStrain(t *testing.T) {
+	tests := []struct {
+		name     string
+		week     []float64
+		monotony float64
+		strain   float64
+	}{
+		{"alternating days", []float64{100, 0, 100, 0, 100, 0, 100}, 1.15, 460},
+		{"similar days", []float64{50, 60, 50, 60, 50, 60, 50}, 10.97, 4168.6},
+		{"identical days", repeat(80, acuteDays), 0, 0},
+		{"rest week", repeat(0, acuteDays), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monotony, strain := monotonyStrain(tt.week)
+			if math.Abs(monotony-tt.monotony) > 1e-9 || math.Abs(strain-tt.strain) > 1e-6 {
+				t.Errorf("monotonyStrain(%v) = %g, %g, want %g, %g", tt.week, monotony, strain, tt.monotony, tt.strain)
+			}
+		})
+	}
+}
+
+func TestSessionRPELoad(t *testing.T) {
+	start := time.Date(2024, time.March, 4, 18, 0, 0, 0, time.UTC)
+	workout := func(difficulty int, duration time.Duration) models.FullWorkout {
+		return models.FullWorkout{
+			StartedAt:   primitive.NewDateTimeFromTime(start),
+			WorkoutDate: primitive.NewDateTimeFromTime(start.Add(duration)),
+			Metadata:    &models.WorkoutMetadata{Difficulty: difficulty},
+		}
+	}
+	tests := []struct {
+		name    string
+		workout models.FullWorkout
+		load    float64
+		ok      bool
+	}{
+		{"hour at 7", workout(7, time.Hour), 420, true},
+		{"90 minutes at 5", workout(5, 90*time.Minute), 450, true},
+		{"no difficulty", workout(0, time.Hour), 0, false},
+		{"no metadata", models.FullWorkout{StartedAt: primitive.NewDateTimeFromTime(start)}, 0, false},
+		{"no start", models.FullWorkout{Metadata: &models.WorkoutMetadata{Difficulty: 7}}, 0, false},
+		{"left open", workout(7, 5*time.Hour), 0, false},
+		{"finished before it started", workout(7, -time.Minute), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load, ok := sessionRPELoad(tt.workout)
+			if load != tt.load || ok != tt.ok {
+				t.Errorf("sessionRPELoad() = %g, %v, want %g, %v", load, ok, tt.load, tt.ok)
+			}
+		})
+	}
+}
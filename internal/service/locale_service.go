@@ -0,0 +1,150 @@
+package service
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/models"
+)
+
+// PreferredLocales orders the supported languages a response should use: the
+// user's saved locale first, then the Accept-Language header by quality, and
+// always ending with the default locale.
+func PreferredLocales(userLocale, acceptLanguage string) []string {
+	var locales []string
+	add := func(tag string) {
+		base := baseLanguage(tag)
+		if slices.Contains(config.AppConfig.SupportedLocales, base) && !slices.Contains(locales, base) {
+			locales = append(locales, base)
+		}
+	}
+
+	if userLocale != "" {
+		add(userLocale)
+	}
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		add(tag)
+	}
+	if !slices.Contains(locales, config.DefaultLocale) {
+		locales = append(locales, config.DefaultLocale)
+	}
+
+	return locales
+}
+
+// IsSupportedLocale reports whether catalog content can be served in locale.
+func IsSupportedLocale(locale string) bool {
+	return slices.Contains(config.AppConfig.SupportedLocales, baseLanguage(locale))
+}
+
+// LocalizeExercise returns a copy of the exercise with its name, aliases,
+// category, variations, equipment and coaching text in the first preferred
+// locale it has a translation for. Untranslated fields keep their English values.
+func LocalizeExercise(exercise models.Exercise, locales []string) models.Exercise {
+	localized := exercise
+	localized.Translations = nil
+	localized.Locale = config.DefaultLocale
+
+	for _, locale := range locales {
+		if locale == config.DefaultLocale {
+			break
+		}
+		t, ok := exercise.Translations[locale]
+		if !ok {
+			continue
+		}
+
+		localized.Locale = locale
+		if t.Name != "" {
+			localized.Name = t.Name
+		}
+		if len(t.Aliases) > 0 {
+			localized.Aliases = t.Aliases
+		}
+		if t.Category != "" {
+			localized.Category = t.Category
+		}
+		if len(t.Instructions) > 0 {
+			localized.Instructions = t.Instructions
+		}
+		if len(t.Cues) > 0 {
+			localized.Cues = t.Cues
+		}
+		if len(t.CommonMistakes) > 0 {
+			localized.CommonMistakes = t.CommonMistakes
+		}
+		localized.Variations = translateValues(exercise.Variations, t.Variations)
+		localized.Equipment = translateValues(exercise.Equipment, t.Equipment)
+		break
+	}
+
+	return localized
+}
+
+func translateValues(values []string, translations map[string]string) []string {
+	if len(translations) == 0 {
+		return values
+	}
+	translated := make([]string, len(values))
+	for i, v := range values {
+		if t, ok := translations[v]; ok && t != "" {
+			translated[i] = t
+		} else {
+			translated[i] = v
+		}
+	}
+	return translated
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language header
+// ordered by quality, dropping those with q=0.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// baseLanguage reduces a tag like "de-AT" or "es_MX" to "de" or "es".
+func baseLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+	"fitness-tracker/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidMeasurement = errors.New("invalid body measurement")
+
+// DefaultTrendWindow is the moving average window of body trends, in days.
+const DefaultTrendWindow = 7
+
+// ApplyMeasurementDTO copies the fields present in dto onto measurement,
+// converting from the user's weight unit and its length unit. A plain date is
+// midnight in loc, the user's time zone. A zero value clears a field. The
+// result must still hold at least one value.
+func ApplyMeasurementDTO(measurement *models.BodyMeasurement, dto models.BodyMeasurementDTO, unit string, loc *time.Location) error {
+	if dto.Date != "" {
+		date, err := utils.ParseDateParamIn(dto.Date, false, loc)
+		if err != nil {
+			return ErrInvalidMeasurement
+		}
+		measurement.Date = primitive.NewDateTimeFromTime(date)
+	}
+
+	if dto.Bodyweight != nil {
+		if *dto.Bodyweight < 0 {
+			return ErrInvalidMeasurement
+		}
+		measurement.Bodyweight = units.ToKg(*dto.Bodyweight, unit)
+	}
+
+	if dto.BodyFat != nil {
+		if *dto.BodyFat < 0 || *dto.BodyFat >= 100 {
+			return ErrInvalidMeasurement
+		}
+		measurement.BodyFat = *dto.BodyFat
+	}
+
+	lengthUnit := units.LengthUnit(unit)
+	for site, value := range dto.Circumferences {
+		if !slices.Contains(models.MeasurementSites, site) || value < 0 {
+			return ErrInvalidMeasurement
+		}
+		if value == 0 {
+			delete(measurement.Circumferences, site)
+			continue
+		}
+		if measurement.Circumferences == nil {
+			measurement.Circumferences = map[string]float64{}
+		}
+		measurement.Circumferences[site] = units.ToCm(value, lengthUnit)
+	}
+
+	if dto.Notes != nil {
+		measurement.Notes = *dto.Notes
+	}
+
+	if measurement.Bodyweight == 0 && measurement.BodyFat == 0 && len(measurement.Circumferences) == 0 {
+		return ErrInvalidMeasurement
+	}
+	return nil
+}
+
+// BodyweightLog looks up a user's bodyweight at a point in time from their
+// measurement log, falling back to the weight on their profile.
+type BodyweightLog struct {
+	entries  []models.BodyMeasurement
+	fallback float64
+}
+
+// LoadBodyweightLog reads the user's logged bodyweights with one query.
+func LoadBodyweightLog(userID primitive.ObjectID) BodyweightLog {
+	bodyweights := BodyweightLog{}
+	if user, err := database.GetUserByID(userID); err == nil {
+		bodyweights.fallback = user.Weight
+	}
+	if entries, err := database.GetBodyweightLog(userID); err == nil {
+		bodyweights.entries = entries
+	}
+	return bodyweights
+}
+
+// At returns the last bodyweight logged on or before t in kilograms. Before
+// the first entry the profile weight is used, or the first entry when the
+// profile has none. Zero means the bodyweight is unknown.
+func (l BodyweightLog) At(t time.Time) float64 {
+	i := sort.Search(len(l.entries), func(i int) bool {
+		return l.entries[i].Date.Time().After(t)
+	})
+	if i > 0 {
+		return l.entries[i-1].Bodyweight
+	}
+	if l.fallback > 0 || len(l.entries) == 0 {
+		return l.fallback
+	}
+	return l.entries[0].Bodyweight
+}
+
+// SyncProfileBodyweight copies the most recent logged bodyweight to the user's
+// profile, so that User.Weight stays current for clients that read it.
+func SyncProfileBodyweight(userID primitive.ObjectID) error {
+	entries, err := database.GetBodyweightLog(userID)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	return database.UpdateUser(userID, bson.M{"weight": entries[len(entries)-1].Bodyweight})
+}
+
+// LatestBodyweight returns the user's most recent bodyweight in kilograms.
+func LatestBodyweight(userID primitive.ObjectID) float64 {
+	return LoadBodyweightLog(userID).At(time.Now())
+}
+
+// WorkoutBodyweight is the bodyweight recorded with the workout, or else the
+// one logged on or before its date.
+func WorkoutBodyweight(workout models.FullWorkout, bodyweights BodyweightLog) float64 {
+	if workout.Metadata != nil && workout.Metadata.Bodyweight > 0 {
+		return workout.Metadata.Bodyweight
+	}
+	return bodyweights.At(workout.WorkoutDate.Time())
+}
+
+// bodyweightEquipment is equipment that adds to, or merely supports, the
+// lifter's own bodyweight, keyed by normalizeEquipment.
+var bodyweightEquipment = map[string]bool{
+	"":                true,
+	"none":            true,
+	"bodyweight":      true,
+	"weighted vest":   true,
+	"dip belt":        true,
+	"pull-up bar":     true,
+	"dip station":     true,
+	"push-up handle":  true,
+	"captain's chair": true,
+}
+
+// BodyweightEquipmentNames lists the lowercase spellings of the equipment
+// that counts as bodyweight, for queries that can't call normalizeEquipment.
+func BodyweightEquipmentNames() []string {
+	names := make([]string, 0, 2*len(bodyweightEquipment))
+	for key := range bodyweightEquipment {
+		names = append(names, key)
+		// Missing equipment has no plural
+		if key != "" {
+			names = append(names, key+"s")
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBodyweightExercise reports whether a logged exercise moves the lifter's
+// bodyweight, so that set weights are added on top of it: the catalog entry
+// lists bodyweight and it was done without an external load such as a
+// barbell or machine.
+func IsBodyweightExercise(exercise models.Exercise, equipment string) bool {
+	listed := false
+	for _, e := range exercise.Equipment {
+		if normalizeEquipment(e) == "bodyweight" {
+			listed = true
+			break
+		}
+	}
+	return listed && bodyweightEquipment[normalizeEquipment(equipment)]
+}
+
+// RelativeStrength is a weight as a multiple of bodyweight, zero when the
+// bodyweight is unknown.
+func RelativeStrength(weight, bodyweight float64) float64 {
+	if bodyweight <= 0 {
+		return 0
+	}
+	return units.Round(weight/bodyweight, 0.01)
+}
+
+// BodyTrendPoint is a log entry with the moving averages ending on its date.
+// Values the entry doesn't record are zero.
+type BodyTrendPoint struct {
+	Date              time.Time `json:"date"`
+	Bodyweight        float64   `json:"bodyweight,omitempty"`
+	BodyweightAverage float64   `json:"bodyweight_average,omitempty"`
+	BodyFat           float64   `json:"body_fat,omitempty"`
+	BodyFatAverage    float64   `json:"body_fat_average,omitempty"`
+}
+
+// BodyTrend summarises bodyweight and body fat over a period. The weekly
+// changes are the least-squares slopes of the entries in the period, per
+// seven days.
+type BodyTrend struct {
+	From                time.Time        `json:"from"`
+	To                  time.Time        `json:"to"`
+	Window              int              `json:"window_days"`
+	Points              []BodyTrendPoint `json:"points"`
+	WeeklyChange        float64          `json:"weekly_change"`
+	WeeklyBodyFatChange float64          `json:"weekly_body_fat_change"`
+	Unit                string           `json:"unit,omitempty"`
+}
+
+// BodyTrendFor computes the user's body trend for entries in [from, to) with a
+// moving average over the preceding window days.
+func BodyTrendFor(userID primitive.ObjectID, from, to time.Time, window int) (*BodyTrend, error) {
+	if window <= 0 {
+		window = DefaultTrendWindow
+	}
+	span := time.Duration(window) * 24 * time.Hour
+
+	// Entries just before the period feed the first averages
+	entries, err := database.GetUserMeasurements(userID, from.Add(-span), to)
+	if err != nil {
+		return nil, err
+	}
+
+	trend := &BodyTrend{From: from, To: to, Window: window, Points: []BodyTrendPoint{}}
+	var weights, fats []trendSample
+
+	for i, entry := range entries {
+		date := entry.Date.Time()
+		if date.Before(from) {
+			continue
+		}
+
+		point := BodyTrendPoint{Date: date, Bodyweight: entry.Bodyweight, BodyFat: entry.BodyFat}
+		var weightSum, fatSum, weightN, fatN float64
+		for j := i; j >= 0 && entries[j].Date.Time().After(date.Add(-span)); j-- {
+			if entries[j].Bodyweight > 0 {
+				weightSum += entries[j].Bodyweight
+				weightN++
+			}
+			if entries[j].BodyFat > 0 {
+				fatSum += entries[j].BodyFat
+				fatN++
+			}
+		}
+		if entry.Bodyweight > 0 {
+			point.BodyweightAverage = weightSum / weightN
+			weights = append(weights, trendSample{date, entry.Bodyweight})
+		}
+		if entry.BodyFat > 0 {
+			point.BodyFatAverage = fatSum / fatN
+			fats = append(fats, trendSample{date, entry.BodyFat})
+		}
+		trend.Points = append(trend.Points, point)
+	}
+
+	trend.WeeklyChange = weeklySlope(weights)
+	trend.WeeklyBodyFatChange = weeklySlope(fats)
+	return trend, nil
+}
+
+type trendSample struct {
+	date  time.Time
+	value float64
+}
+
+// weeklySlope fits a line through the samples and returns its slope per
+// week, zero when they don't span at least a day.
+func weeklySlope(samples []trendSample) float64 {
+	slope, _, ok := linearFit(samples)
+	if !ok {
+		return 0
+	}
+	return slope * 7
+}
+
+// linearFit fits a least-squares line through the samples, returning its
+// slope per day and its value at the first sample's date. It fails when the
+// samples don't span at least a day.
+func linearFit(samples []trendSample) (slope, intercept float64, ok bool) {
+	if len(samples) < 2 || samples[len(samples)-1].date.Sub(samples[0].date) < 24*time.Hour {
+		return 0, 0, false
+	}
+
+	origin := samples[0].date
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := s.date.Sub(origin).Hours() / 24
+		sumX += x
+		sumY += s.value
+		sumXY += x * s.value
+		sumXX += x * x
+	}
+
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, 0, false
+	}
+	slope = (n*sumXY - sumX*sumY) / denominator
+	intercept = (sumY - slope*sumX) / n
+	return slope, intercept, true
+}
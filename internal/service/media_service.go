@@ -0,0 +1,186 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/storage"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrUnsupportedMedia = errors.New("unsupported media type")
+	ErrMediaTooLarge    = errors.New("media file too large")
+	ErrMediaNotFound    = errors.New("media not found")
+)
+
+// MediaTypes lists the accepted upload content types and the size cap.
+type MediaTypes struct {
+	Images      []string `json:"images"`
+	Videos      []string `json:"videos"`
+	MaxUploadMB int      `json:"max_upload_mb"`
+}
+
+func AllowedMediaTypes() MediaTypes {
+	cfg := config.AppConfig.Media
+	return MediaTypes{
+		Images:      cfg.AllowedImages,
+		Videos:      cfg.AllowedVideos,
+		MaxUploadMB: cfg.MaxUploadMB,
+	}
+}
+
+// MaxUploadBytes is the configured upload cap in bytes.
+func MaxUploadBytes() int64 {
+	return int64(config.AppConfig.Media.MaxUploadMB) << 20
+}
+
+// mediaKind classifies an allowed content type as an image or video.
+func mediaKind(contentType string) (string, bool) {
+	cfg := config.AppConfig.Media
+	switch {
+	case slices.Contains(cfg.AllowedImages, contentType):
+		return models.MediaImage, true
+	case slices.Contains(cfg.AllowedVideos, contentType):
+		return models.MediaVideo, true
+	}
+	return "", false
+}
+
+// UploadExerciseMedia validates and stores a demo image or video for an
+// exercise. The content type is sniffed from the file itself; a declared type
+// only has to agree on whether it is an image or a video, since clients often
+// send loose values like "image/jpg".
+func UploadExerciseMedia(exercise models.Exercise, uploaderID primitive.ObjectID, file io.Reader, declaredType, caption string) (models.ExerciseMedia, error) {
+	buffered := bufio.NewReaderSize(file, 512)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return models.ExerciseMedia{}, err
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	kind, ok := mediaKind(contentType)
+	if !ok {
+		return models.ExerciseMedia{}, ErrUnsupportedMedia
+	}
+	if declared, _, err := mime.ParseMediaType(declaredType); err == nil && declared != "application/octet-stream" {
+		if !strings.HasPrefix(declared, kind+"/") {
+			return models.ExerciseMedia{}, ErrUnsupportedMedia
+		}
+	}
+
+	media := models.ExerciseMedia{
+		ID:          primitive.NewObjectID(),
+		Kind:        kind,
+		ContentType: contentType,
+		Caption:     strings.TrimSpace(caption),
+		UploadedBy:  uploaderID,
+		UploadedAt:  primitive.NewDateTimeFromTime(time.Now()),
+	}
+	media.Key = "exercises/" + exercise.ID.Hex() + "/" + media.ID.Hex() + mediaExtension(contentType)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	maxBytes := MaxUploadBytes()
+	media.Size, err = storage.Blobs.Put(ctx, media.Key, io.LimitReader(buffered, maxBytes+1))
+	if err != nil {
+		return models.ExerciseMedia{}, err
+	}
+	if media.Size > maxBytes {
+		storage.Blobs.Delete(ctx, media.Key)
+		return models.ExerciseMedia{}, ErrMediaTooLarge
+	}
+
+	if err := database.AddExerciseMedia(exercise.ID, media); err != nil {
+		storage.Blobs.Delete(ctx, media.Key)
+		return models.ExerciseMedia{}, err
+	}
+
+	return media, nil
+}
+
+// OpenExerciseMedia returns the stored file behind one of the exercise's
+// media references. The caller closes the reader.
+func OpenExerciseMedia(exercise models.Exercise, mediaID primitive.ObjectID) (io.ReadCloser, models.ExerciseMedia, error) {
+	media, ok := findMedia(exercise, mediaID)
+	if !ok {
+		return nil, models.ExerciseMedia{}, ErrMediaNotFound
+	}
+
+	blob, err := storage.Blobs.Get(context.Background(), media.Key)
+	if err == storage.ErrBlobNotFound {
+		return nil, models.ExerciseMedia{}, ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, models.ExerciseMedia{}, err
+	}
+
+	return blob, media, nil
+}
+
+// DeleteExerciseMedia removes a media reference and its stored file.
+func DeleteExerciseMedia(exercise models.Exercise, mediaID primitive.ObjectID) error {
+	media, ok := findMedia(exercise, mediaID)
+	if !ok {
+		return ErrMediaNotFound
+	}
+
+	if err := database.RemoveExerciseMedia(exercise.ID, mediaID); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return storage.Blobs.Delete(ctx, media.Key)
+}
+
+// deleteMediaBlobs removes the stored files of an exercise that is being
+// deleted. Failures are left as orphaned files rather than blocking the delete.
+func deleteMediaBlobs(exercise models.Exercise) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, media := range exercise.Media {
+		storage.Blobs.Delete(ctx, media.Key)
+	}
+}
+
+func findMedia(exercise models.Exercise, mediaID primitive.ObjectID) (models.ExerciseMedia, bool) {
+	for _, media := range exercise.Media {
+		if media.ID == mediaID {
+			return media, true
+		}
+	}
+	return models.ExerciseMedia{}, false
+}
+
+func mediaExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "video/mp4":
+		return ".mp4"
+	case "video/webm":
+		return ".webm"
+	}
+	return ""
+}
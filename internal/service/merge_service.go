@@ -0,0 +1,271 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidMerge = errors.New("an exercise can't be merged into itself")
+	ErrMergeScope   = errors.New("a private exercise can only absorb exercises of the same owner")
+)
+
+// MergeReport counts what a merge rewrote.
+type MergeReport struct {
+	SourceID  primitive.ObjectID `json:"source_id"`
+	TargetID  primitive.ObjectID `json:"target_id"`
+	Routines  int64              `json:"routines"`
+	Workouts  int64              `json:"workouts"`
+	Sessions  int64              `json:"sessions"`
+	Archived  int64              `json:"archived_sessions"`
+	Goals     int64              `json:"goals"`
+	Histories int                `json:"histories"`
+}
+
+// DuplicateGroup is a set of exercises whose names or aliases collide once
+// case, punctuation and plurals are ignored.
+type DuplicateGroup struct {
+	Keys      []string          `json:"keys"`
+	Exercises []models.Exercise `json:"exercises"`
+}
+
+// MergeExercises folds source into target: the source name, aliases,
+// variations, equipment and media are added to the target, every reference in
+// routines, workouts, sessions, archived sessions, goals and exercise history
+// is rewritten, and the source is deleted. A private target only takes exercises
+// of its owner, so global or other users' exercises never disappear into it.
+// Steps are ordered, and history entries the target already holds are
+// skipped, so that re-running an interrupted merge finishes it.
+func MergeExercises(sourceID, targetID primitive.ObjectID) (*MergeReport, error) {
+	if sourceID == targetID {
+		return nil, ErrInvalidMerge
+	}
+
+	source, err := database.GetExerciseData(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := database.GetExerciseData(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if !target.OwnerID.IsZero() && source.OwnerID != target.OwnerID {
+		return nil, ErrMergeScope
+	}
+
+	aliases := mergeStrings(target.Aliases, append([]string{source.Name}, source.Aliases...), target.Name)
+	err = database.UpdateExercise(targetID, bson.M{
+		"aliases":    aliases,
+		"variations": mergeStrings(target.Variations, source.Variations, ""),
+		"equipment":  mergeStrings(target.Equipment, source.Equipment, ""),
+		"media":      mergeMedia(target.Media, source.Media),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MergeReport{SourceID: sourceID, TargetID: targetID}
+
+	if report.Routines, err = database.ReplaceExerciseReferences("routines", sourceID, targetID, target.Name); err != nil {
+		return nil, err
+	}
+	if report.Workouts, err = database.ReplaceExerciseReferences("workouts", sourceID, targetID, target.Name); err != nil {
+		return nil, err
+	}
+	if report.Sessions, err = database.ReplaceExerciseReferences("sessions", sourceID, targetID, target.Name); err != nil {
+		return nil, err
+	}
+	if report.Archived, err = database.ReplaceExerciseReferences("sessionArchive", sourceID, targetID, target.Name); err != nil {
+		return nil, err
+	}
+	if report.Goals, err = database.ReplaceGoalExercise(sourceID, targetID); err != nil {
+		return nil, err
+	}
+
+	histories, err := database.GetExerciseHistoriesForExercise(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, history := range histories {
+		// Append into the user's target history (creating it if needed),
+		// keeping entries in date order, then drop the source history. An
+		// earlier run may have appended before failing to delete, so entries
+		// already in the target are left out.
+		existing, err := database.GetExerciseHistoryData(targetID, history.UserID)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		if missing := missingEntries(existing.Sets, history.Sets); len(missing) > 0 {
+			updates := bson.M{
+				"$push": bson.M{
+					"exerciseSets": bson.M{
+						"$each": missing,
+						"$sort": bson.M{"date": 1},
+					},
+				},
+			}
+			if err := database.UpdateExerciseHistory(targetID, history.UserID, updates); err != nil {
+				return nil, err
+			}
+		}
+		if err := database.DeleteExerciseHistory(history.ID); err != nil {
+			return nil, err
+		}
+		report.Histories++
+	}
+
+	if err := database.DeleteExercise(sourceID); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// FindDuplicateExercises groups global catalog exercises that share a
+// normalized name or alias.
+func FindDuplicateExercises() ([]DuplicateGroup, error) {
+	exercises, err := database.SearchExercises(database.ExerciseFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Union-find over exercise indexes, joined through shared keys
+	parent := make([]int, len(exercises))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owner := map[string]int{}
+	keysOf := make([]map[string]bool, len(exercises))
+	for i, exercise := range exercises {
+		keysOf[i] = map[string]bool{}
+		for _, name := range append([]string{exercise.Name}, exercise.Aliases...) {
+			key := duplicateKey(name)
+			if key == "" || keysOf[i][key] {
+				continue
+			}
+			keysOf[i][key] = true
+			if j, ok := owner[key]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				owner[key] = i
+			}
+		}
+	}
+
+	members := map[int][]int{}
+	for i := range exercises {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+
+	groups := []DuplicateGroup{}
+	for _, idx := range members {
+		if len(idx) < 2 {
+			continue
+		}
+
+		keyCount := map[string]int{}
+		group := DuplicateGroup{Keys: []string{}}
+		for _, i := range idx {
+			group.Exercises = append(group.Exercises, exercises[i])
+			for key := range keysOf[i] {
+				keyCount[key]++
+			}
+		}
+		for key, count := range keyCount {
+			if count > 1 {
+				group.Keys = append(group.Keys, key)
+			}
+		}
+		sort.Strings(group.Keys)
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Exercises[0].Name < groups[j].Exercises[0].Name
+	})
+
+	return groups, nil
+}
+
+// duplicateKey lower-cases a name, drops punctuation and spaces and strips a
+// plural "s", so "Pull-Ups" and "pullup" share a key.
+func duplicateKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "s")
+}
+
+// mergeStrings appends the values of extra missing from base (ignoring case),
+// skipping exclude.
+func mergeStrings(base, extra []string, exclude string) []string {
+	merged := append([]string{}, base...)
+	for _, v := range extra {
+		if v == "" || strings.EqualFold(v, exclude) {
+			continue
+		}
+		found := false
+		for _, m := range merged {
+			if strings.EqualFold(m, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, v)
+		}
+	}
+	return merged
+}
+
+// missingEntries returns the history entries of extra that base doesn't
+// already hold, compared on date, equipment, variation and sets.
+func missingEntries(base, extra []models.ExerciseSets) []models.ExerciseSets {
+	missing := []models.ExerciseSets{}
+	for _, entry := range extra {
+		found := false
+		for _, b := range base {
+			if reflect.DeepEqual(b, entry) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, entry)
+		}
+	}
+	return missing
+}
+
+// mergeMedia appends the media of extra not already referenced by base.
+func mergeMedia(base, extra []models.ExerciseMedia) []models.ExerciseMedia {
+	merged := append([]models.ExerciseMedia{}, base...)
+	for _, m := range extra {
+		if _, found := findMedia(models.Exercise{Media: merged}, m.ID); !found {
+			merged = append(merged, m)
+		}
+	}
+	return merged
+}
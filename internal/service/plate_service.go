@@ -0,0 +1,303 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidInventory = errors.New("invalid equipment inventory")
+	ErrUnknownBar       = errors.New("no such bar in the inventory")
+)
+
+// Inventories are limited so the plate search stays cheap. Weights are in
+// the inventory's unit.
+const (
+	maxPlateKinds  = 20
+	maxPlateCount  = 40
+	maxPlateWeight = 100
+	maxPlateTotal  = 2000
+	maxBarWeight   = 100
+	// maxPlateSum bounds the per-side sums searched, in hundredths.
+	maxPlateSum = maxPlateTotal / 2 * 100
+)
+
+// PlateLoading is how to load a bar for a target weight. Weights are in Unit,
+// the unit of the inventory. Total is the nearest loadable weight, which can
+// differ from Target when the plates don't add up exactly.
+type PlateLoading struct {
+	Unit      string    `json:"unit"`
+	Target    float64   `json:"target"`
+	Total     float64   `json:"total"`
+	Bar       string    `json:"bar"`
+	BarWeight float64   `json:"bar_weight"`
+	PerSide   []float64 `json:"per_side"`
+}
+
+// DefaultInventory is a commercial gym with a standard barbell and pairs of
+// every common plate, used for the plate calculator until the user saves
+// their own inventory.
+func DefaultInventory(userID primitive.ObjectID, unit string) models.EquipmentInventory {
+	if unit == units.Lb {
+		return models.EquipmentInventory{
+			UserID: userID,
+			Unit:   units.Lb,
+			Bars:   []models.Bar{{Name: "Barbell", Weight: 45}, {Name: "EZ Bar", Weight: 25}},
+			Plates: []models.PlateStock{
+				{Weight: 45, Count: 8}, {Weight: 35, Count: 2}, {Weight: 25, Count: 4},
+				{Weight: 10, Count: 4}, {Weight: 5, Count: 4}, {Weight: 2.5, Count: 2},
+			},
+		}
+	}
+	return models.EquipmentInventory{
+		UserID: userID,
+		Unit:   units.Kg,
+		Bars:   []models.Bar{{Name: "Barbell", Weight: 20}, {Name: "EZ Bar", Weight: 10}},
+		Plates: []models.PlateStock{
+			{Weight: 25, Count: 8}, {Weight: 20, Count: 2}, {Weight: 15, Count: 2},
+			{Weight: 10, Count: 4}, {Weight: 5, Count: 4}, {Weight: 2.5, Count: 2}, {Weight: 1.25, Count: 2},
+		},
+	}
+}
+
+// ValidateInventory normalizes the unit and rejects negative or oversized
+// entries.
+func ValidateInventory(inventory *models.EquipmentInventory) error {
+	inventory.Unit = units.Normalize(inventory.Unit)
+
+	for _, bar := range inventory.Bars {
+		if strings.TrimSpace(bar.Name) == "" || bar.Weight < 0 || bar.Weight > maxBarWeight {
+			return ErrInvalidInventory
+		}
+	}
+	if len(inventory.Plates) > maxPlateKinds {
+		return ErrInvalidInventory
+	}
+	total := 0.0
+	for _, plate := range inventory.Plates {
+		if plate.Weight <= 0 || plate.Weight > maxPlateWeight || plate.Count < 0 || plate.Count > maxPlateCount {
+			return ErrInvalidInventory
+		}
+		total += plate.Weight * float64(plate.Count)
+	}
+	if total > maxPlateTotal {
+		return ErrInvalidInventory
+	}
+	if d := inventory.Dumbbells; d != nil {
+		if d.Min < 0 || d.Max < d.Min || d.Increment <= 0 {
+			return ErrInvalidInventory
+		}
+	}
+	if inventory.MachineStep < 0 {
+		return ErrInvalidInventory
+	}
+	return nil
+}
+
+// UserInventory returns the user's saved inventory, or false if they haven't
+// described their equipment.
+func UserInventory(userID primitive.ObjectID) (*models.EquipmentInventory, bool) {
+	inventory, err := database.GetEquipmentInventory(userID)
+	if err != nil {
+		return nil, false
+	}
+	return &inventory, true
+}
+
+// LoadBar works out the plates to put on each side of the named bar (the
+// first bar when barName is empty) for a target weight in the inventory's
+// unit. The result is the closest loadable weight, preferring the lighter one
+// on a tie.
+func LoadBar(inventory models.EquipmentInventory, barName string, target float64) (PlateLoading, error) {
+	bar, ok := findBar(inventory, barName)
+	if !ok {
+		return PlateLoading{}, ErrUnknownBar
+	}
+
+	loading := PlateLoading{
+		Unit:      inventory.Unit,
+		Target:    target,
+		Total:     bar.Weight,
+		Bar:       bar.Name,
+		BarWeight: bar.Weight,
+		PerSide:   []float64{},
+	}
+	if target <= bar.Weight {
+		return loading, nil
+	}
+
+	perSide := nearestPlateSum(inventory.Plates, (target-bar.Weight)/2)
+	for _, p := range perSide {
+		loading.Total += 2 * p
+	}
+	loading.Total = math.Round(loading.Total*100) / 100
+	loading.PerSide = perSide
+	return loading, nil
+}
+
+// RoundToLoadable rounds a prescribed weight in kilograms to what the user can
+// actually load with the given equipment: bar plus plates for barbells, the
+// dumbbell range for dumbbells and the stack step for selectorised machines
+// and cables. Other equipment, and equipment the inventory doesn't describe,
+// is left as is.
+func RoundToLoadable(inventory models.EquipmentInventory, equipment string, kg float64) float64 {
+	if kg <= 0 {
+		return kg
+	}
+	weight := units.FromKg(kg, inventory.Unit)
+	key := normalizeEquipment(equipment)
+
+	switch {
+	case key == "dumbbell":
+		d := inventory.Dumbbells
+		if d == nil {
+			return kg
+		}
+		weight = math.Min(math.Max(units.Round(weight-d.Min, d.Increment)+d.Min, d.Min), d.Max)
+	case barbellEquipment[key]:
+		barName := equipment
+		if _, ok := findBar(inventory, barName); !ok {
+			barName = ""
+		}
+		loading, err := LoadBar(inventory, barName, weight)
+		if err != nil {
+			return kg
+		}
+		weight = loading.Total
+	case (strings.Contains(key, "machine") && !strings.Contains(key, "plate")) || strings.Contains(key, "cable"):
+		if inventory.MachineStep <= 0 {
+			return kg
+		}
+		weight = math.Max(units.Round(weight, inventory.MachineStep), inventory.MachineStep)
+	default:
+		return kg
+	}
+
+	return units.ToKg(weight, inventory.Unit)
+}
+
+// OwnedEquipment returns the options the user has access to according to the
+// inventory. Without an equipment list every option counts as owned.
+func OwnedEquipment(inventory *models.EquipmentInventory, options []string) []string {
+	if inventory == nil || len(inventory.Equipment) == 0 {
+		return options
+	}
+	owned := sharedEquipment(options, inventory.Equipment)
+	for _, o := range options {
+		if key := normalizeEquipment(o); key == "none" || key == "bodyweight" {
+			owned = append(owned, o)
+		}
+	}
+	return owned
+}
+
+// chooseEquipment keeps the preferred equipment when the user owns it and
+// otherwise falls back to the first owned option.
+func chooseEquipment(preferred string, owned []string) string {
+	if len(owned) == 0 || normalizeEquipment(preferred) == "none" {
+		return preferred
+	}
+	for _, o := range owned {
+		if normalizeEquipment(o) == normalizeEquipment(preferred) {
+			return o
+		}
+	}
+	return owned[0]
+}
+
+func findBar(inventory models.EquipmentInventory, name string) (models.Bar, bool) {
+	if len(inventory.Bars) == 0 {
+		return models.Bar{}, false
+	}
+	if name == "" {
+		return inventory.Bars[0], true
+	}
+	for _, bar := range inventory.Bars {
+		if normalizeEquipment(bar.Name) == normalizeEquipment(name) {
+			return bar, true
+		}
+	}
+	return models.Bar{}, false
+}
+
+// barbellEquipment is the catalog equipment loaded with a bar and plates,
+// keyed by normalizeEquipment. A bar of the same name in the inventory is
+// used when present, otherwise the first bar.
+var barbellEquipment = map[string]bool{
+	"barbell":       true,
+	"ez bar":        true,
+	"trap bar":      true,
+	"safety bar":    true,
+	"smith machine": true,
+}
+
+// nearestPlateSum picks plates for one side of the bar whose sum is closest to
+// target. Plates come in pairs, so each side gets half of every stock. Sums
+// are searched in hundredths with a subset-sum table, remembering the plate
+// that first reached every sum to rebuild the combination.
+func nearestPlateSum(stock []models.PlateStock, target float64) []float64 {
+	var plates []int
+	for _, p := range stock {
+		for n := 0; n < p.Count/2; n++ {
+			plates = append(plates, int(math.Round(p.Weight*100)))
+		}
+	}
+	// Heaviest first so that equal sums are built from fewer plates
+	sort.Sort(sort.Reverse(sort.IntSlice(plates)))
+
+	// Inventories saved before validation was tightened are bounded here;
+	// heavier sums are treated as unreachable
+	max := 0
+	for _, p := range plates {
+		max += p
+	}
+	if max > maxPlateSum {
+		max = maxPlateSum
+	}
+
+	// via[s] is the plate that first reached sum s, 0 if unreachable
+	via := make([]int, max+1)
+	reachable := make([]bool, max+1)
+	reachable[0] = true
+	for _, p := range plates {
+		if p <= 0 || p > max {
+			continue
+		}
+		for s := max; s >= p; s-- {
+			if !reachable[s] && reachable[s-p] {
+				reachable[s] = true
+				via[s] = p
+			}
+		}
+	}
+
+	goal := int(math.Round(target * 100))
+	best := 0
+	for s := range reachable {
+		if reachable[s] && abs(s-goal) < abs(best-goal) {
+			best = s
+		}
+	}
+
+	perSide := []float64{}
+	for s := best; s > 0; s -= via[s] {
+		perSide = append(perSide, float64(via[s])/100)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(perSide)))
+	return perSide
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
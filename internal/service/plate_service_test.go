@@ -0,0 +1,154 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidateInventory(t *testing.T) {
+	valid := func() models.EquipmentInventory {
+		return DefaultInventory(primitive.NilObjectID, units.Kg)
+	}
+	tests := []struct {
+		name   string
+		modify func(*models.EquipmentInventory)
+		ok     bool
+	}{
+		{"default", func(*models.EquipmentInventory) {}, true},
+		{"unnamed bar", func(i *models.EquipmentInventory) { i.Bars[0].Name = " " }, false},
+		{"negative bar", func(i *models.EquipmentInventory) { i.Bars[0].Weight = -1 }, false},
+		{"heavy bar", func(i *models.EquipmentInventory) { i.Bars[0].Weight = maxBarWeight + 1 }, false},
+		{"zero plate", func(i *models.EquipmentInventory) { i.Plates[0].Weight = 0 }, false},
+		{"heavy plate", func(i *models.EquipmentInventory) { i.Plates[0].Weight = maxPlateWeight + 1 }, false},
+		{"negative count", func(i *models.EquipmentInventory) { i.Plates[0].Count = -2 }, false},
+		{"too many plates", func(i *models.EquipmentInventory) { i.Plates[0].Count = maxPlateCount + 2 }, false},
+		{"too much weight", func(i *models.EquipmentInventory) {
+			i.Plates = []models.PlateStock{{Weight: 50, Count: 40}, {Weight: 45, Count: 40}}
+		}, false},
+		{"too many kinds", func(i *models.EquipmentInventory) {
+			i.Plates = nil
+			for n := 1; n <= maxPlateKinds+1; n++ {
+				i.Plates = append(i.Plates, models.PlateStock{Weight: float64(n) / 10, Count: 2})
+			}
+		}, false},
+		{"dumbbells", func(i *models.EquipmentInventory) {
+			i.Dumbbells = &models.DumbbellRange{Min: 2, Max: 50, Increment: 2}
+		}, true},
+		{"inverted dumbbells", func(i *models.EquipmentInventory) {
+			i.Dumbbells = &models.DumbbellRange{Min: 50, Max: 2, Increment: 2}
+		}, false},
+		{"no dumbbell increment", func(i *models.EquipmentInventory) {
+			i.Dumbbells = &models.DumbbellRange{Min: 2, Max: 50}
+		}, false},
+		{"negative machine step", func(i *models.EquipmentInventory) { i.MachineStep = -5 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory := valid()
+			tt.modify(&inventory)
+			if err := ValidateInventory(&inventory); (err == nil) != tt.ok {
+				t.Errorf("ValidateInventory() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateInventoryNormalizesUnit(t *testing.T) {
+	inventory := models.EquipmentInventory{Unit: "lbs"}
+	if err := ValidateInventory(&inventory); err != nil || inventory.Unit != units.Lb {
+		t.Errorf("ValidateInventory() = %v with unit %q, want nil with %q", err, inventory.Unit, units.Lb)
+	}
+}
+
+func TestNearestPlateSum(t *testing.T) {
+	kg := DefaultInventory(primitive.NilObjectID, units.Kg).Plates
+	tests := []struct {
+		name   string
+		stock  []models.PlateStock
+		target float64
+		want   []float64
+	}{
+		{"exact", kg, 40, []float64{25, 15}},
+		{"nearest below", kg, 40.5, []float64{25, 15}},
+		{"nearest above", kg, 41, []float64{25, 15, 1.25}},
+		{"small plates", kg, 3.75, []float64{2.5, 1.25}},
+		{"more than owned", kg, 500, []float64{25, 25, 25, 25, 20, 15, 10, 10, 5, 5, 2.5, 1.25}},
+		{"odd plate out", []models.PlateStock{{Weight: 20, Count: 1}}, 20, []float64{}},
+		{"nothing", nil, 10, []float64{}},
+		{"tie prefers lighter", []models.PlateStock{{Weight: 10, Count: 2}}, 5, []float64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nearestPlateSum(tt.stock, tt.target); !slices.Equal(got, tt.want) {
+				t.Errorf("nearestPlateSum(%g) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBar(t *testing.T) {
+	kg := DefaultInventory(primitive.NilObjectID, units.Kg)
+	tests := []struct {
+		name   string
+		bar    string
+		target float64
+		total  float64
+		err    error
+	}{
+		{"first bar", "", 100, 100, nil},
+		{"rounded", "", 101, 100, nil},
+		{"below the bar", "", 15, 20, nil},
+		{"named bar", "EZ Bars", 30, 30, nil},
+		{"unknown bar", "Trap Bar", 100, 0, ErrUnknownBar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loading, err := LoadBar(kg, tt.bar, tt.target)
+			if err != tt.err || loading.Total != tt.total {
+				t.Errorf("LoadBar(%q, %g) = %g, %v, want %g, %v", tt.bar, tt.target, loading.Total, err, tt.total, tt.err)
+			}
+		})
+	}
+}
+
+func TestRoundToLoadable(t *testing.T) {
+	kg := DefaultInventory(primitive.NilObjectID, units.Kg)
+	kg.Dumbbells = &models.DumbbellRange{Min: 2, Max: 50, Increment: 2}
+	kg.MachineStep = 5
+	lb := DefaultInventory(primitive.NilObjectID, units.Lb)
+
+	tests := []struct {
+		name      string
+		inventory models.EquipmentInventory
+		equipment string
+		kg        float64
+		want      float64
+	}{
+		{"barbell", kg, "Barbell", 101, 100},
+		{"ez bar", kg, "EZ Bar", 31, 30},
+		{"trap bar falls back to the first bar", kg, "Trap Bar", 61, 60},
+		{"dumbbell", kg, "Dumbbells", 22.9, 22},
+		{"dumbbell above the range", kg, "Dumbbell", 60, 50},
+		{"dumbbell below the range", kg, "Dumbbell", 1, 2},
+		{"machine", kg, "Machine", 37, 35},
+		{"cable below one step", kg, "Cable", 1, 5},
+		{"plate-loaded machine", kg, "Plate Loaded Machine", 37, 37},
+		{"unknown equipment", kg, "Kettlebell", 17, 17},
+		{"no weight", kg, "Barbell", 0, 0},
+		{"pounds", lb, "Barbell", 100, units.ToKg(220, units.Lb)},
+		{"no dumbbells described", lb, "Dumbbell", 23, 23},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundToLoadable(tt.inventory, tt.equipment, tt.kg); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("RoundToLoadable(%q, %g) = %g, want %g", tt.equipment, tt.kg, got, tt.want)
+			}
+		})
+	}
+}
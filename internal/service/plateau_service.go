@@ -0,0 +1,322 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuses of a flagged exercise.
+const (
+	StatusPlateau    = "plateau"
+	StatusRegression = "regression"
+)
+
+// How an exercise's performance is measured: the best estimated one-rep max
+// of a session, or the most reps in a set for unloaded bodyweight work.
+const (
+	MetricE1RM = "e1rm"
+	MetricReps = "reps"
+)
+
+// Kinds of suggested interventions.
+const (
+	InterventionVariation = "variation"
+	InterventionRepRange  = "rep_range"
+	InterventionDeload    = "deload"
+)
+
+const (
+	// maxVariationSuggestions limits the variations suggested per exercise.
+	maxVariationSuggestions = 2
+	// deloadFactor is the share of the recent top set to deload to.
+	deloadFactor = 0.9
+)
+
+// Intervention is a suggested way out of a plateau. Weight is the deload
+// weight and only set for deloads.
+type Intervention struct {
+	Kind      string  `json:"kind"`
+	Variation string  `json:"variation,omitempty"`
+	RepRange  string  `json:"rep_range,omitempty"`
+	Weight    float64 `json:"weight,omitempty"`
+	Reason    string  `json:"reason"`
+}
+
+// ExercisePlateau is an exercise that stalled or regressed. Current is the
+// best session of the window and Previous the best of the window before it,
+// or the start of the window's trend line when there was none. Change is the
+// relative difference between the two.
+type ExercisePlateau struct {
+	ExerciseID   primitive.ObjectID `json:"exercise_id"`
+	Name         string             `json:"name"`
+	Status       string             `json:"status"`
+	Metric       string             `json:"metric"`
+	Current      float64            `json:"current"`
+	Previous     float64            `json:"previous"`
+	Change       float64            `json:"change"`
+	Sessions     int                `json:"sessions"`
+	LastPR       time.Time          `json:"last_pr"`
+	WeeksSincePR float64            `json:"weeks_since_pr"`
+	Suggestions  []Intervention     `json:"suggestions"`
+}
+
+// PlateauReport lists the user's stalled and regressing exercises,
+// regressions first and then the longest stalled.
+type PlateauReport struct {
+	Weeks     int               `json:"weeks"`
+	Since     time.Time         `json:"since"`
+	Exercises []ExercisePlateau `json:"exercises"`
+	Unit      string            `json:"unit,omitempty"`
+}
+
+// plateauSession is an exercise's performance in one session.
+type plateauSession struct {
+	date      time.Time
+	value     float64
+	topWeight float64
+	reps      []int
+	variation string
+}
+
+// PlateauReportFor scans the user's exercise history for exercises whose
+// performance over the last weeks hasn't improved on the weeks before.
+// Exercises with fewer sessions in the window than configured are skipped.
+func PlateauReportFor(userID primitive.ObjectID, weeks int, now time.Time) (*PlateauReport, error) {
+	cfg := config.AppConfig.Analytics
+	if weeks <= 0 {
+		weeks = cfg.PlateauWeeks
+	}
+	window := time.Duration(weeks) * 7 * 24 * time.Hour
+	since := now.Add(-window)
+
+	histories, err := database.GetUserExerciseHistories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PlateauReport{Weeks: weeks, Since: since, Exercises: []ExercisePlateau{}}
+	recent := map[primitive.ObjectID][]plateauSession{}
+
+	for _, history := range histories {
+		metric := plateauMetric(history, since)
+		sessions := plateauSessions(history, metric)
+
+		var current, previous []plateauSession
+		for _, s := range sessions {
+			switch {
+			case !s.date.Before(since) && !s.date.After(now):
+				current = append(current, s)
+			case !s.date.Before(since.Add(-window)) && s.date.Before(since):
+				previous = append(previous, s)
+			}
+		}
+		if len(current) == 0 || len(current) < cfg.PlateauMinSessions {
+			continue
+		}
+
+		plateau := ExercisePlateau{
+			ExerciseID: history.ExerciseID,
+			Metric:     metric,
+			Current:    bestSession(current),
+			Sessions:   len(current),
+		}
+		if len(previous) > 0 {
+			plateau.Previous = bestSession(previous)
+		} else {
+			// Without an earlier window, compare along the window's trend
+			samples := make([]trendSample, len(current))
+			for i, s := range current {
+				samples[i] = trendSample{s.date, s.value}
+			}
+			slope, intercept, ok := linearFit(samples)
+			if !ok {
+				continue
+			}
+			plateau.Previous = intercept
+			plateau.Current = intercept + slope*current[len(current)-1].date.Sub(current[0].date).Hours()/24
+		}
+		if plateau.Previous <= 0 {
+			continue
+		}
+
+		plateau.Change = units.Round((plateau.Current-plateau.Previous)/plateau.Previous, 0.001)
+		switch {
+		case plateau.Change <= -cfg.RegressionThreshold:
+			plateau.Status = StatusRegression
+		case plateau.Change < cfg.PlateauThreshold:
+			plateau.Status = StatusPlateau
+		default:
+			continue
+		}
+
+		plateau.LastPR = lastPR(sessions)
+		plateau.WeeksSincePR = units.Round(now.Sub(plateau.LastPR).Hours()/24/7, 0.1)
+		report.Exercises = append(report.Exercises, plateau)
+		recent[history.ExerciseID] = current
+	}
+
+	if len(report.Exercises) == 0 {
+		return report, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(report.Exercises))
+	for _, p := range report.Exercises {
+		ids = append(ids, p.ExerciseID)
+	}
+	catalog, err := database.GetExercisesByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range report.Exercises {
+		p := &report.Exercises[i]
+		exercise := catalog[p.ExerciseID]
+		p.Name = exercise.Name
+		p.Suggestions = suggestInterventions(*p, exercise, recent[p.ExerciseID], weeks)
+	}
+
+	sort.SliceStable(report.Exercises, func(i, j int) bool {
+		a, b := report.Exercises[i], report.Exercises[j]
+		if a.Status != b.Status {
+			return a.Status == StatusRegression
+		}
+		if a.Status == StatusRegression {
+			return a.Change < b.Change
+		}
+		return a.WeeksSincePR > b.WeeksSincePR
+	})
+	return report, nil
+}
+
+// plateauMetric measures by e1RM unless no set in the window carried weight.
+func plateauMetric(history models.ExerciseHistory, since time.Time) string {
+	for _, entry := range history.Sets {
+		if entry.Date.Time().Before(since) {
+			continue
+		}
+		for _, s := range entry.WorkoutSets {
+			if s.Weight > 0 {
+				return MetricE1RM
+			}
+		}
+	}
+	return MetricReps
+}
+
+// plateauSessions measures every logged session of the history, oldest
+// first. Sessions without a measurable set are left out.
+func plateauSessions(history models.ExerciseHistory, metric string) []plateauSession {
+	var sessions []plateauSession
+	for _, entry := range history.Sets {
+		session := plateauSession{date: entry.Date.Time(), variation: entry.Variation}
+		for _, s := range entry.WorkoutSets {
+			if s.Reps <= 0 {
+				continue
+			}
+			value := float64(s.Reps)
+			if metric == MetricE1RM {
+				value = EstimateOneRepMax(s.Weight, s.Reps)
+			}
+			session.value = math.Max(session.value, value)
+			session.topWeight = math.Max(session.topWeight, s.Weight)
+			session.reps = append(session.reps, s.Reps)
+		}
+		if session.value > 0 {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].date.Before(sessions[j].date) })
+	return sessions
+}
+
+func bestSession(sessions []plateauSession) float64 {
+	best := 0.0
+	for _, s := range sessions {
+		best = math.Max(best, s.value)
+	}
+	return best
+}
+
+// lastPR is the date of the session that last beat every session before it.
+func lastPR(sessions []plateauSession) time.Time {
+	var best float64
+	var date time.Time
+	for _, s := range sessions {
+		if s.value > best {
+			best = s.value
+			date = s.date
+		}
+	}
+	return date
+}
+
+// suggestInterventions proposes variations of the exercise not trained in
+// the window, a change away from the rep range it has been trained in, and a
+// deload for regressions or plateaus lasting more than two windows.
+func suggestInterventions(plateau ExercisePlateau, exercise models.Exercise, sessions []plateauSession, weeks int) []Intervention {
+	suggestions := []Intervention{}
+
+	used := map[string]bool{}
+	for _, s := range sessions {
+		used[strings.ToLower(strings.TrimSpace(s.variation))] = true
+	}
+	for _, v := range exercise.Variations {
+		key := strings.ToLower(strings.TrimSpace(v))
+		if key == "" || key == "none" || used[key] {
+			continue
+		}
+		suggestions = append(suggestions, Intervention{
+			Kind:      InterventionVariation,
+			Variation: v,
+			Reason:    fmt.Sprintf("Not trained in the last %d weeks", weeks),
+		})
+		if len(suggestions) == maxVariationSuggestions {
+			break
+		}
+	}
+
+	if plateau.Metric == MetricE1RM {
+		var total, n float64
+		for _, s := range sessions {
+			for _, reps := range s.reps {
+				total += float64(reps)
+				n++
+			}
+		}
+		average := total / n
+		rangeTo := "4-6"
+		switch {
+		case average <= 5:
+			rangeTo = "8-12"
+		case average <= 8:
+			rangeTo = "3-5"
+		}
+		suggestions = append(suggestions, Intervention{
+			Kind:     InterventionRepRange,
+			RepRange: rangeTo,
+			Reason:   fmt.Sprintf("Sets have averaged %.1f reps", average),
+		})
+	}
+
+	if plateau.Status == StatusRegression || plateau.WeeksSincePR > float64(2*weeks) {
+		deload := Intervention{Kind: InterventionDeload, Reason: "Performance has regressed"}
+		if plateau.Status != StatusRegression {
+			deload.Reason = fmt.Sprintf("No PR in %.0f weeks", plateau.WeeksSincePR)
+		}
+		if plateau.Metric == MetricE1RM {
+			deload.Weight = sessions[len(sessions)-1].topWeight * deloadFactor
+		}
+		suggestions = append(suggestions, deload)
+	}
+	return suggestions
+}
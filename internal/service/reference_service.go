@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExerciseReferenceError describes the first invalid exercise entry in a
+// routine or session payload.
+type ExerciseReferenceError struct {
+	Index  int
+	Field  string
+	Value  string
+	Reason string
+}
+
+func (e *ExerciseReferenceError) Error() string {
+	return fmt.Sprintf("exercises[%d].%s %q: %s", e.Index, e.Field, e.Value, e.Reason)
+}
+
+// ResolveRoutineExercises checks that every exercise exists and is visible to
+// the user, and takes names from the catalog instead of the client.
+func ResolveRoutineExercises(userID primitive.ObjectID, entries []models.RoutineExerciseDTO) ([]models.RoutineExercise, error) {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ExerciseID
+	}
+
+	catalog, err := lookupExercises(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	exercises := make([]models.RoutineExercise, 0, len(entries))
+	for i, entry := range entries {
+		exercise := catalog[i]
+		exercises = append(exercises, models.RoutineExercise{
+			ExerciseID: exercise.ID,
+			Name:       exercise.Name,
+			TargetSets: entry.TargetSets,
+			TargetReps: entry.TargetReps,
+		})
+	}
+
+	return exercises, nil
+}
+
+// ResolveSessionExercises validates a session update against the catalog:
+// every exercise must exist, and equipment and variation must be one the
+// exercise lists (in English or a translation), "None", or the value the
+// session already holds for that exercise. Names and values are stored in
+// their canonical English form.
+func ResolveSessionExercises(session models.WorkoutSession, entries []models.WorkoutExerciseDTO) ([]models.WorkoutExercise, error) {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ExerciseID
+	}
+
+	catalog, err := lookupExercises(session.UserID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values already on the session stay valid so that sessions prescribed
+	// from older history keep working.
+	current := map[primitive.ObjectID]models.WorkoutExercise{}
+	for _, ex := range session.Exercises {
+		current[ex.ExerciseID] = ex
+	}
+
+	exercises := make([]models.WorkoutExercise, 0, len(entries))
+	for i, entry := range entries {
+		exercise := catalog[i]
+		existing := current[exercise.ID]
+
+		equipment, ok := catalogValue(entry.Equipment, existing.Equipment, exercise.Equipment, exercise, func(t models.ExerciseTranslation) map[string]string { return t.Equipment })
+		if !ok {
+			return nil, &ExerciseReferenceError{Index: i, Field: "equipment", Value: entry.Equipment, Reason: "not listed for " + exercise.Name}
+		}
+		variation, ok := catalogValue(entry.Variation, existing.Variation, exercise.Variations, exercise, func(t models.ExerciseTranslation) map[string]string { return t.Variations })
+		if !ok {
+			return nil, &ExerciseReferenceError{Index: i, Field: "variation", Value: entry.Variation, Reason: "not listed for " + exercise.Name}
+		}
+
+		exercises = append(exercises, models.WorkoutExercise{
+			ExerciseID: exercise.ID,
+			Name:       exercise.Name,
+			Equipment:  equipment,
+			Variation:  variation,
+			Sets:       entry.Sets,
+			Notes:      entry.Notes,
+
+			EquipmentOptions: existing.EquipmentOptions,
+		})
+	}
+
+	return exercises, nil
+}
+
+// lookupExercises loads the catalog entries for the given hex IDs with one
+// query, in order, rejecting malformed IDs, unknown exercises and other users'
+// custom exercises.
+func lookupExercises(userID primitive.ObjectID, hexIDs []string) ([]models.Exercise, error) {
+	ids := make([]primitive.ObjectID, len(hexIDs))
+	for i, hex := range hexIDs {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, &ExerciseReferenceError{Index: i, Field: "exercise_id", Value: hex, Reason: "invalid id"}
+		}
+		ids[i] = id
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	found, err := database.GetExercisesByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	exercises := make([]models.Exercise, len(ids))
+	for i, id := range ids {
+		exercise, ok := found[id]
+		if !ok || (!exercise.OwnerID.IsZero() && exercise.OwnerID != userID) {
+			return nil, &ExerciseReferenceError{Index: i, Field: "exercise_id", Value: hexIDs[i], Reason: "no such exercise"}
+		}
+		exercises[i] = exercise
+	}
+
+	return exercises, nil
+}
+
+// catalogValue maps a submitted equipment or variation string to its
+// canonical catalog value. Empty values are stored as "None".
+func catalogValue(value, existing string, allowed []string, exercise models.Exercise, translations func(models.ExerciseTranslation) map[string]string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "None") {
+		return "None", true
+	}
+	if existing != "" && value == existing {
+		return existing, true
+	}
+
+	for _, a := range allowed {
+		if strings.EqualFold(a, value) {
+			return a, true
+		}
+	}
+	for _, t := range exercise.Translations {
+		for english, translated := range translations(t) {
+			if strings.EqualFold(translated, value) {
+				return english, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Formats a summary report can be rendered in besides JSON.
+const (
+	ReportMarkdown = "markdown"
+	ReportHTML     = "html"
+)
+
+// reportFuncs are shared by the Markdown and HTML templates.
+var reportFuncs = map[string]any{
+	"date": func(t time.Time) string { return t.Format("Jan 2, 2006") },
+	// last is the final day of a period given its exclusive end
+	"last":    func(t time.Time) string { return t.AddDate(0, 0, -1).Format("Jan 2, 2006") },
+	"num":     formatNumber,
+	"signed":  func(v float64) string { return signed(v, formatNumber(v)) },
+	"signedi": func(v int) string { return signed(float64(v), strconv.Itoa(v)) },
+	"percent": func(v float64) string { return strconv.FormatFloat(v*100, 'f', 0, 64) + "%" },
+	"muscle":  func(m string) string { return strings.ReplaceAll(m, "_", " ") },
+	"md":      markdownEscape.Replace,
+	"title": func(period string) string {
+		if period == PeriodMonth {
+			return "Monthly"
+		}
+		return "Weekly"
+	},
+}
+
+var markdownReport = texttemplate.Must(texttemplate.New("report").Funcs(reportFuncs).Parse(
+	`# {{title .Period}} training summary
+
+{{date .From}} – {{last .To}} ({{.TimeZone}})
+
+| | This {{.Period}} | Previous | Change |
+|---|---:|---:|---:|
+| Workouts | {{.Totals.Workouts}} | {{.Previous.Workouts}} | {{signedi .Change.Workouts}} |
+| Sets | {{.Totals.Sets}} | {{.Previous.Sets}} | {{signedi .Change.Sets}} |
+| Volume ({{.Unit}}) | {{num .Totals.Volume}} | {{num .Previous.Volume}} | {{signed .Change.Volume}}{{if .Previous.Volume}} ({{signed .Change.VolumePercent}}%){{end}} |
+{{if .TopLifts}}
+## Top lifts
+
+| Exercise | Sets | Volume ({{.Unit}}) | Best set | e1RM ({{.Unit}}) |
+|---|---:|---:|---:|---:|
+{{range .TopLifts}}| {{md .Name}} | {{.Sets}} | {{num .Volume}} | {{if .Reps}}{{num .Weight}} × {{.Reps}}{{else}}–{{end}} | {{if .E1RM}}{{num .E1RM}}{{else}}–{{end}} |
+{{end}}{{end}}
+## Personal records
+{{if .PRs}}
+{{range .PRs}}- **{{md .Name}}**: {{num .Weight}} {{$.Unit}} × {{.Reps}} on {{date .Date}}, e1RM {{num .E1RM}} {{$.Unit}} (was {{num .Previous}})
+{{end}}{{else}}
+No new records this {{.Period}}.
+{{end}}{{if .Muscles}}
+## Muscle groups
+
+| Muscle | Sets | Share |
+|---|---:|---:|
+{{range .Muscles}}| {{muscle .Muscle}} | {{num .Sets}} | {{percent .Share}} |
+{{end}}{{end}}
+## Adherence
+{{if .Adherence.PlannedWorkouts}}
+- Workouts: {{.Totals.Workouts}} of {{num .Adherence.PlannedWorkouts}} planned ({{percent .Adherence.WorkoutRate}})
+{{- end}}
+{{if .Adherence.TargetSets}}- Routine sets: {{.Adherence.CompletedSets}} of {{.Adherence.TargetSets}} ({{percent .Adherence.SetRate}})
+{{else}}- No routine workouts to measure.
+{{end}}`))
+
+var htmlReport = htmltemplate.Must(htmltemplate.New("report").Funcs(reportFuncs).Parse(
+	`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{title .Period}} training summary</title>
+</head>
+<body style="font-family: sans-serif; max-width: 640px; margin: 0 auto;">
+<h1>{{title .Period}} training summary</h1>
+<p>{{date .From}} – {{last .To}} ({{.TimeZone}})</p>
+
+<table cellpadding="4">
+<tr><th></th><th align="right">This {{.Period}}</th><th align="right">Previous</th><th align="right">Change</th></tr>
+<tr><td>Workouts</td><td align="right">{{.Totals.Workouts}}</td><td align="right">{{.Previous.Workouts}}</td><td align="right">{{signedi .Change.Workouts}}</td></tr>
+<tr><td>Sets</td><td align="right">{{.Totals.Sets}}</td><td align="right">{{.Previous.Sets}}</td><td align="right">{{signedi .Change.Sets}}</td></tr>
+<tr><td>Volume ({{.Unit}})</td><td align="right">{{num .Totals.Volume}}</td><td align="right">{{num .Previous.Volume}}</td><td align="right">{{signed .Change.Volume}}{{if .Previous.Volume}} ({{signed .Change.VolumePercent}}%){{end}}</td></tr>
+</table>
+{{if .TopLifts}}
+<h2>Top lifts</h2>
+<table cellpadding="4">
+<tr><th align="left">Exercise</th><th align="right">Sets</th><th align="right">Volume ({{.Unit}})</th><th align="right">Best set</th><th align="right">e1RM ({{.Unit}})</th></tr>
+{{range .TopLifts}}<tr><td>{{.Name}}</td><td align="right">{{.Sets}}</td><td align="right">{{num .Volume}}</td><td align="right">{{if .Reps}}{{num .Weight}} × {{.Reps}}{{else}}–{{end}}</td><td align="right">{{if .E1RM}}{{num .E1RM}}{{else}}–{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Personal records</h2>
+{{if .PRs}}<ul>
+{{range .PRs}}<li><strong>{{.Name}}</strong>: {{num .Weight}} {{$.Unit}} × {{.Reps}} on {{date .Date}}, e1RM {{num .E1RM}} {{$.Unit}} (was {{num .Previous}})</li>
+{{end}}</ul>
+{{else}}<p>No new records this {{.Period}}.</p>
+{{end}}{{if .Muscles}}
+<h2>Muscle groups</h2>
+<table cellpadding="4">
+<tr><th align="left">Muscle</th><th align="right">Sets</th><th align="right">Share</th></tr>
+{{range .Muscles}}<tr><td>{{muscle .Muscle}}</td><td align="right">{{num .Sets}}</td><td align="right">{{percent .Share}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Adherence</h2>
+<ul>
+{{if .Adherence.PlannedWorkouts}}<li>Workouts: {{.Totals.Workouts}} of {{num .Adherence.PlannedWorkouts}} planned ({{percent .Adherence.WorkoutRate}})</li>
+{{end}}{{if .Adherence.TargetSets}}<li>Routine sets: {{.Adherence.CompletedSets}} of {{.Adherence.TargetSets}} ({{percent .Adherence.SetRate}})</li>
+{{else}}<li>No routine workouts to measure.</li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// RenderSummaryReport renders a report, already converted for display, as
+// Markdown or as an HTML page suitable for email.
+func RenderSummaryReport(report *SummaryReport, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == ReportHTML {
+		err = htmlReport.Execute(&buf, report)
+	} else {
+		err = markdownReport.Execute(&buf, report)
+	}
+	return buf.Bytes(), err
+}
+
+// markdownEscape backslash-escapes the characters that would start Markdown
+// formatting or end a table cell in a user-chosen name, and folds line breaks
+// so a name stays on its row.
+var markdownEscape = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "|", `\|`, "#", `\#`, "\r\n", " ", "\n", " ", "\r", " ",
+)
+
+// formatNumber drops the decimals of whole numbers and keeps one otherwise.
+func formatNumber(v float64) string {
+	if v == float64(int64(v)) {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+func signed(v float64, s string) string {
+	if v > 0 {
+		return "+" + s
+	}
+	return s
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkdownEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Bench Press", "Bench Press"},
+		{"Row | cable", `Row \| cable`},
+		{"*Paused* squat_2", `\*Paused\* squat\_2`},
+		{"[Link](x) <b>", `\[Link\](x) \<b\>`},
+		{"# Front `squat` \\", "\\# Front \\`squat\\` \\\\"},
+		{"Two\nlines\r\nhere", "Two lines here"},
+	}
+	for _, tt := range tests {
+		if got := markdownEscape.Replace(tt.name); got != tt.want {
+			t.Errorf("markdownEscape(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{100, "100"},
+		{102.5, "102.5"},
+		{102.25, "102.2"},
+		{0, "0"},
+		{-7.5, "-7.5"},
+	}
+	for _, tt := range tests {
+		if got := formatNumber(tt.v); got != tt.want {
+			t.Errorf("formatNumber(%g) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestRenderSummaryReport(t *testing.T) {
+	week := &SummaryReport{
+		Period:   PeriodWeek,
+		From:     time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+		TimeZone: "Europe/Berlin",
+		Totals:   PeriodTotals{Workouts: 3, Sets: 45, Volume: 12500},
+		Previous: PeriodTotals{Workouts: 2, Sets: 30, Volume: 10000},
+		Change:   PeriodChange{Workouts: 1, Sets: 15, Volume: 2500, VolumePercent: 25},
+		TopLifts: []TopLift{{Name: "Squat | *paused*", Sets: 5, Volume: 3000, Weight: 120, Reps: 5, E1RM: 140}},
+		PRs: []PersonalRecord{{
+			Name: "<b>Bench</b>", Date: time.Date(2024, time.March, 6, 0, 0, 0, 0, time.UTC),
+			Weight: 100, Reps: 3, E1RM: 110, Previous: 107.5,
+		}},
+		Muscles:   []MuscleShare{{Muscle: "upper_back", Sets: 12.5, Share: 0.25}},
+		Adherence: Adherence{PlannedWorkouts: 4, WorkoutRate: 0.75, TargetSets: 50, CompletedSets: 45, SetRate: 0.9},
+		Unit:      "kg",
+	}
+	quiet := &SummaryReport{
+		Period:   PeriodMonth,
+		From:     time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		TimeZone: "UTC",
+		Unit:     "lb",
+	}
+
+	tests := []struct {
+		name    string
+		report  *SummaryReport
+		format  string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "markdown week", report: week, format: ReportMarkdown,
+			want: []string{
+				"# Weekly training summary",
+				"Mar 4, 2024 – Mar 10, 2024 (Europe/Berlin)",
+				"| Volume (kg) | 12500 | 10000 | +2500 (+25%) |",
+				`| Squat \| \*paused\* | 5 | 3000 | 120 × 5 | 140 |`,
+				`- **\<b\>Bench\</b\>**: 100 kg × 3 on Mar 6, 2024, e1RM 110 kg (was 107.5)`,
+				"| upper back | 12.5 | 25% |",
+				"- Workouts: 3 of 4 planned (75%)",
+				"- Routine sets: 45 of 50 (90%)",
+			},
+			notWant: []string{"No new records"},
+		},
+		{
+			name: "markdown quiet month", report: quiet, format: ReportMarkdown,
+			want: []string{
+				"# Monthly training summary",
+				"Feb 1, 2024 – Feb 29, 2024 (UTC)",
+				"| Volume (lb) | 0 | 0 | 0 |",
+				"No new records this month.",
+				"- No routine workouts to measure.",
+			},
+			notWant: []string{"## Top lifts", "## Muscle groups", "planned", "(+"},
+		},
+		{
+			name: "html week", report: week, format: ReportHTML,
+			want: []string{
+				"<title>Weekly training summary</title>",
+				"<td>Squat | *paused*</td>",
+				"<strong>&lt;b&gt;Bench&lt;/b&gt;</strong>",
+				"<li>Workouts: 3 of 4 planned (75%)</li>",
+			},
+			notWant: []string{"<b>Bench</b>", `\|`},
+		},
+		{
+			name: "html quiet month", report: quiet, format: ReportHTML,
+			want:    []string{"<p>No new records this month.</p>", "<li>No routine workouts to measure.</li>"},
+			notWant: []string{"<h2>Top lifts</h2>", "<h2>Muscle groups</h2>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := RenderSummaryReport(tt.report, tt.format)
+			if err != nil {
+				t.Fatalf("RenderSummaryReport() error = %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(string(out), s) {
+					t.Errorf("output is missing %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(string(out), s) {
+					t.Errorf("output contains %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
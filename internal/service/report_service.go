@@ -0,0 +1,416 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidPeriod = errors.New("period must be week or month")
+
+// Report periods. Weeks start on Monday.
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// maxTopLifts is how many exercises the summary lists as top lifts.
+const maxTopLifts = 5
+
+// PeriodTotals sums the workouts of a period.
+type PeriodTotals struct {
+	Workouts int     `json:"workouts"`
+	Sets     int     `json:"sets"`
+	Reps     int     `json:"reps"`
+	Volume   float64 `json:"volume"`
+}
+
+// PeriodChange compares a period with the one before it. VolumePercent is
+// zero when the previous period had no volume.
+type PeriodChange struct {
+	Workouts      int     `json:"workouts"`
+	Sets          int     `json:"sets"`
+	Volume        float64 `json:"volume"`
+	VolumePercent float64 `json:"volume_percent"`
+}
+
+// TopLift is one of the exercises with the most volume in the period, with
+// its best set by estimated one-rep max.
+type TopLift struct {
+	ExerciseID primitive.ObjectID `json:"exercise_id"`
+	Name       string             `json:"name"`
+	Sets       int                `json:"sets"`
+	Volume     float64            `json:"volume"`
+	Weight     float64            `json:"weight"`
+	Reps       int                `json:"reps"`
+	E1RM       float64            `json:"e1rm"`
+}
+
+// PersonalRecord is the best estimated one-rep max of an exercise in the
+// period when it beat everything the user lifted before. First attempts at
+// an exercise don't count.
+type PersonalRecord struct {
+	ExerciseID primitive.ObjectID `json:"exercise_id"`
+	Name       string             `json:"name"`
+	Date       time.Time          `json:"date"`
+	Weight     float64            `json:"weight"`
+	Reps       int                `json:"reps"`
+	E1RM       float64            `json:"e1rm"`
+	Previous   float64            `json:"previous"`
+}
+
+// MuscleShare is a muscle group's part of the period's working sets, with
+// secondary muscles credited as in the muscle volume analytics.
+type MuscleShare struct {
+	Muscle string  `json:"muscle"`
+	Sets   float64 `json:"sets"`
+	Share  float64 `json:"share"`
+}
+
+// Adherence measures the period against the plan. Planned workouts come
+// from an active workouts-per-week goal and are left out without one; target
+// sets are those of the routines the workouts followed.
+type Adherence struct {
+	PlannedWorkouts float64 `json:"planned_workouts,omitempty"`
+	WorkoutRate     float64 `json:"workout_rate,omitempty"`
+	TargetSets      int     `json:"target_sets"`
+	CompletedSets   int     `json:"completed_sets"`
+	SetRate         float64 `json:"set_rate,omitempty"`
+}
+
+// SummaryReport summarises a week or month of training. To is exclusive.
+type SummaryReport struct {
+	Period    string           `json:"period"`
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	TimeZone  string           `json:"time_zone"`
+	Totals    PeriodTotals     `json:"totals"`
+	Previous  PeriodTotals     `json:"previous"`
+	Change    PeriodChange     `json:"change"`
+	TopLifts  []TopLift        `json:"top_lifts"`
+	PRs       []PersonalRecord `json:"prs"`
+	Muscles   []MuscleShare    `json:"muscles"`
+	Adherence Adherence        `json:"adherence"`
+	Unit      string           `json:"unit,omitempty"`
+}
+
+// PeriodBounds returns the week or month containing t, in t's location.
+func PeriodBounds(period string, t time.Time) (from, to time.Time, err error) {
+	switch period {
+	case PeriodWeek:
+		from = WeekStart(t)
+		return from, from.AddDate(0, 0, 7), nil
+	case PeriodMonth:
+		from = monthStart(t)
+		return from, from.AddDate(0, 1, 0), nil
+	}
+	return time.Time{}, time.Time{}, ErrInvalidPeriod
+}
+
+// SummaryReportFor summarises the user's period that contains day, which
+// should be in the user's time zone. Workouts count for the day they
+// started on.
+func SummaryReportFor(userID primitive.ObjectID, period string, day time.Time) (*SummaryReport, error) {
+	from, to, err := PeriodBounds(period, day)
+	if err != nil {
+		return nil, err
+	}
+	previousFrom, _, _ := PeriodBounds(period, from.AddDate(0, 0, -1))
+	loc := from.Location()
+
+	// A day either side catches workouts saved on another date than they
+	// started
+	workouts, err := database.GetUserWorkoutsInRange(userID, previousFrom.AddDate(0, 0, -1), to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	var current, previous, earlier []models.FullWorkout
+	for _, workout := range workouts {
+		date := WorkoutDay(workout, loc)
+		switch {
+		case !date.Before(from) && date.Before(to):
+			current = append(current, workout)
+		case !date.Before(previousFrom) && date.Before(from):
+			previous = append(previous, workout)
+			earlier = append(earlier, workout)
+		case date.Before(previousFrom):
+			earlier = append(earlier, workout)
+		}
+	}
+
+	catalog, err := exercisesForWorkouts(workouts)
+	if err != nil {
+		return nil, err
+	}
+	bodyweights := LoadBodyweightLog(userID)
+
+	report := &SummaryReport{
+		Period:   period,
+		From:     from,
+		To:       to,
+		TimeZone: loc.String(),
+		Totals:   periodTotals(current, catalog, bodyweights),
+		Previous: periodTotals(previous, catalog, bodyweights),
+		TopLifts: topLifts(current, catalog, bodyweights),
+		Muscles:  muscleShares(current, catalog),
+	}
+
+	report.Change = PeriodChange{
+		Workouts: report.Totals.Workouts - report.Previous.Workouts,
+		Sets:     report.Totals.Sets - report.Previous.Sets,
+		Volume:   report.Totals.Volume - report.Previous.Volume,
+	}
+	if report.Previous.Volume > 0 {
+		report.Change.VolumePercent = units.Round(report.Change.Volume/report.Previous.Volume*100, 0.1)
+	}
+
+	if report.PRs, err = personalRecords(userID, current, earlier, previousFrom.AddDate(0, 0, -1), catalog, loc); err != nil {
+		return nil, err
+	}
+	if report.Adherence, err = adherence(userID, current, from, to); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func periodTotals(workouts []models.FullWorkout, catalog map[primitive.ObjectID]models.Exercise, bodyweights BodyweightLog) PeriodTotals {
+	totals := PeriodTotals{Workouts: len(workouts)}
+	for _, workout := range workouts {
+		totals.Volume += workoutVolume(workout, catalog, bodyweights)
+		for _, exercise := range workout.Exercises {
+			if isStaticExercise(exercise.ExerciseID) {
+				continue
+			}
+			for _, s := range exercise.Sets {
+				if s.Reps > 0 {
+					totals.Sets++
+					totals.Reps += s.Reps
+				}
+			}
+		}
+	}
+	return totals
+}
+
+// topLifts ranks the period's exercises by volume.
+func topLifts(workouts []models.FullWorkout, catalog map[primitive.ObjectID]models.Exercise, bodyweights BodyweightLog) []TopLift {
+	lifts := map[primitive.ObjectID]*TopLift{}
+	for _, workout := range workouts {
+		bodyweight := WorkoutBodyweight(workout, bodyweights)
+		for _, exercise := range workout.Exercises {
+			if isStaticExercise(exercise.ExerciseID) {
+				continue
+			}
+			info := catalog[exercise.ExerciseID]
+			load := 0.0
+			if IsBodyweightExercise(info, exercise.Equipment) {
+				load = bodyweight
+			}
+			sets, volume := workingSets(exercise.Sets, load)
+			if sets == 0 {
+				continue
+			}
+
+			lift, ok := lifts[exercise.ExerciseID]
+			if !ok {
+				lift = &TopLift{ExerciseID: exercise.ExerciseID, Name: info.Name}
+				lifts[exercise.ExerciseID] = lift
+			}
+			lift.Sets += int(sets)
+			lift.Volume += volume
+			for _, s := range exercise.Sets {
+				if e1rm := EstimateOneRepMax(s.Weight, s.Reps); e1rm > lift.E1RM {
+					lift.E1RM, lift.Weight, lift.Reps = e1rm, s.Weight, s.Reps
+				}
+			}
+		}
+	}
+
+	top := make([]TopLift, 0, len(lifts))
+	for _, lift := range lifts {
+		top = append(top, *lift)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Volume != top[j].Volume {
+			return top[i].Volume > top[j].Volume
+		}
+		return top[i].Name < top[j].Name
+	})
+	if len(top) > maxTopLifts {
+		top = top[:maxTopLifts]
+	}
+	return top
+}
+
+// personalRecords compares the period's best sets with everything lifted
+// before it: the stored workouts before loadedFrom and the loaded earlier
+// ones.
+func personalRecords(userID primitive.ObjectID, current, earlier []models.FullWorkout, loadedFrom time.Time, catalog map[primitive.ObjectID]models.Exercise, loc *time.Location) ([]PersonalRecord, error) {
+	best := map[primitive.ObjectID]PersonalRecord{}
+	var ids []primitive.ObjectID
+	for _, workout := range current {
+		for _, exercise := range workout.Exercises {
+			if isStaticExercise(exercise.ExerciseID) {
+				continue
+			}
+			for _, s := range exercise.Sets {
+				e1rm := EstimateOneRepMax(s.Weight, s.Reps)
+				if e1rm <= 0 {
+					continue
+				}
+				record, ok := best[exercise.ExerciseID]
+				if !ok {
+					ids = append(ids, exercise.ExerciseID)
+				}
+				if e1rm > record.E1RM {
+					best[exercise.ExerciseID] = PersonalRecord{
+						ExerciseID: exercise.ExerciseID,
+						Name:       catalog[exercise.ExerciseID].Name,
+						Date:       WorkoutDay(workout, loc),
+						Weight:     s.Weight,
+						Reps:       s.Reps,
+						E1RM:       e1rm,
+					}
+				}
+			}
+		}
+	}
+
+	records := []PersonalRecord{}
+	if len(ids) == 0 {
+		return records, nil
+	}
+
+	stored, err := database.GetBestWeightsByReps(userID, ids, loadedFrom, maxE1RMReps)
+	if err != nil {
+		return nil, err
+	}
+	before := map[primitive.ObjectID]float64{}
+	for id, byReps := range stored {
+		for reps, weight := range byReps {
+			before[id] = math.Max(before[id], EstimateOneRepMax(weight, reps))
+		}
+	}
+	for _, workout := range earlier {
+		for _, exercise := range workout.Exercises {
+			for _, s := range exercise.Sets {
+				before[exercise.ExerciseID] = math.Max(before[exercise.ExerciseID], EstimateOneRepMax(s.Weight, s.Reps))
+			}
+		}
+	}
+
+	for _, id := range ids {
+		record := best[id]
+		if previous := before[id]; previous > 0 && record.E1RM > previous {
+			record.Previous = previous
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Date.Before(records[j].Date) })
+	return records, nil
+}
+
+// muscleShares splits the period's working sets over the muscle groups,
+// largest first.
+func muscleShares(workouts []models.FullWorkout, catalog map[primitive.ObjectID]models.Exercise) []MuscleShare {
+	credit := config.AppConfig.Analytics.SecondaryCredit
+	sets := map[string]float64{}
+	total := 0.0
+	for _, workout := range workouts {
+		for _, exercise := range workout.Exercises {
+			info, ok := catalog[exercise.ExerciseID]
+			if !ok || isStaticExercise(exercise.ExerciseID) {
+				continue
+			}
+			count, _ := workingSets(exercise.Sets, 0)
+			for _, m := range info.PrimaryMuscles {
+				sets[m] += count
+				total += count
+			}
+			for _, m := range info.SecondaryMuscles {
+				sets[m] += count * credit
+				total += count * credit
+			}
+		}
+	}
+
+	shares := []MuscleShare{}
+	for _, m := range models.MuscleGroups {
+		if sets[m] > 0 {
+			shares = append(shares, MuscleShare{
+				Muscle: m,
+				Sets:   units.Round(sets[m], 0.1),
+				Share:  units.Round(sets[m]/total, 0.001),
+			})
+		}
+	}
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].Sets > shares[j].Sets })
+	return shares
+}
+
+// adherence compares the workouts with an active workouts-per-week goal,
+// prorated to the period, and their sets with the targets of the routines
+// they followed. Sets beyond a target don't make up for missed ones.
+func adherence(userID primitive.ObjectID, workouts []models.FullWorkout, from, to time.Time) (Adherence, error) {
+	result := Adherence{}
+
+	goals, err := database.GetUserGoals(userID, models.GoalActive)
+	if err != nil {
+		return result, err
+	}
+	for _, goal := range goals {
+		if goal.Type == models.GoalWorkoutsPerWeek {
+			weeks := math.Round(to.Sub(from).Hours()/24) / 7
+			result.PlannedWorkouts = units.Round(goal.Target*weeks, 0.1)
+			if result.PlannedWorkouts > 0 {
+				result.WorkoutRate = units.Round(float64(len(workouts))/result.PlannedWorkouts, 0.01)
+			}
+			break
+		}
+	}
+
+	routines := map[primitive.ObjectID]*models.FullRoutine{}
+	for _, workout := range workouts {
+		if workout.RoutineID.IsZero() {
+			continue
+		}
+		routine, ok := routines[workout.RoutineID]
+		if !ok {
+			if r, err := database.GetRoutineData(userID, workout.RoutineID); err == nil {
+				routine = &r
+			}
+			routines[workout.RoutineID] = routine
+		}
+		if routine == nil {
+			continue
+		}
+
+		done := map[primitive.ObjectID]int{}
+		for _, exercise := range workout.Exercises {
+			count, _ := workingSets(exercise.Sets, 0)
+			done[exercise.ExerciseID] += int(count)
+		}
+		for _, target := range routine.Exercises {
+			if isStaticExercise(target.ExerciseID) || target.TargetSets <= 0 {
+				continue
+			}
+			result.TargetSets += target.TargetSets
+			completed := min(done[target.ExerciseID], target.TargetSets)
+			result.CompletedSets += completed
+			done[target.ExerciseID] -= completed
+		}
+	}
+	if result.TargetSets > 0 {
+		result.SetRate = units.Round(float64(result.CompletedSets)/float64(result.TargetSets), 0.01)
+	}
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodBounds(t *testing.T) {
+	berlin := time.FixedZone("CET", 60*60)
+	tests := []struct {
+		name     string
+		period   string
+		t        time.Time
+		from, to time.Time
+		err      error
+	}{
+		{
+			"week from a wednesday", PeriodWeek, time.Date(2024, time.March, 6, 15, 0, 0, 0, berlin),
+			time.Date(2024, time.March, 4, 0, 0, 0, 0, berlin), time.Date(2024, time.March, 11, 0, 0, 0, 0, berlin), nil,
+		},
+		{
+			"week from a monday", PeriodWeek, time.Date(2024, time.March, 4, 0, 0, 0, 0, berlin),
+			time.Date(2024, time.March, 4, 0, 0, 0, 0, berlin), time.Date(2024, time.March, 11, 0, 0, 0, 0, berlin), nil,
+		},
+		{
+			"week from a sunday", PeriodWeek, time.Date(2024, time.March, 10, 23, 59, 0, 0, berlin),
+			time.Date(2024, time.March, 4, 0, 0, 0, 0, berlin), time.Date(2024, time.March, 11, 0, 0, 0, 0, berlin), nil,
+		},
+		{
+			"week across a year", PeriodWeek, time.Date(2025, time.January, 1, 8, 0, 0, 0, berlin),
+			time.Date(2024, time.December, 30, 0, 0, 0, 0, berlin), time.Date(2025, time.January, 6, 0, 0, 0, 0, berlin), nil,
+		},
+		{
+			"leap february", PeriodMonth, time.Date(2024, time.February, 29, 12, 0, 0, 0, berlin),
+			time.Date(2024, time.February, 1, 0, 0, 0, 0, berlin), time.Date(2024, time.March, 1, 0, 0, 0, 0, berlin), nil,
+		},
+		{
+			"december", PeriodMonth, time.Date(2024, time.December, 31, 23, 0, 0, 0, berlin),
+			time.Date(2024, time.December, 1, 0, 0, 0, 0, berlin), time.Date(2025, time.January, 1, 0, 0, 0, 0, berlin), nil,
+		},
+		{"unknown period", "year", time.Date(2024, time.March, 6, 0, 0, 0, 0, berlin), time.Time{}, time.Time{}, ErrInvalidPeriod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := PeriodBounds(tt.period, tt.t)
+			if err != tt.err || !from.Equal(tt.from) || !to.Equal(tt.to) {
+				t.Errorf("PeriodBounds(%q, %v) = %v, %v, %v, want %v, %v, %v", tt.period, tt.t, from, to, err, tt.from, tt.to, tt.err)
+			}
+			if err == nil && from.Location() != berlin {
+				t.Errorf("PeriodBounds() is in %v, want the location of t", from.Location())
+			}
+		})
+	}
+}
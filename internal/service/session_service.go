@@ -0,0 +1,235 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrSessionState = errors.New("session is not in a valid state for this action")
+
+// MaxSessionExpiryHours is the longest expiry a user may choose.
+const MaxSessionExpiryHours = 30 * 24
+
+// ValidSessionExpiryHours reports whether hours is a whole number of hours a
+// user may set as their expiry; zero restores the configured default.
+func ValidSessionExpiryHours(hours float64) bool {
+	return hours == math.Trunc(hours) && hours >= 0 && hours <= MaxSessionExpiryHours
+}
+
+// SessionExpiry returns how long a session may sit untouched for the given
+// user, preferring the user's own setting over the configured default.
+func SessionExpiry(userID primitive.ObjectID) time.Duration {
+	hours := config.AppConfig.Sessions.ExpiryHours
+	if user, err := database.GetUserByID(userID); err == nil && user.SessionExpiryHours > 0 {
+		hours = user.SessionExpiryHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// TouchSession returns the session updates that mark it as modified now and
+// push its expiry out by the user's expiry window.
+func TouchSession(session models.WorkoutSession) bson.M {
+	now := time.Now()
+	return bson.M{
+		"lastUpdated": primitive.NewDateTimeFromTime(now),
+		"expiresAt":   primitive.NewDateTimeFromTime(now.Add(SessionExpiry(session.UserID))),
+	}
+}
+
+// PauseSession freezes an active session. Paused sessions still expire, but
+// the window restarts from the moment of pausing.
+func PauseSession(sessionID primitive.ObjectID) error {
+	session, err := database.GetSessionData(sessionID)
+	if err != nil {
+		return err
+	}
+	if sessionStatus(session) != models.SessionActive {
+		return ErrSessionState
+	}
+
+	updates := TouchSession(session)
+	updates["status"] = models.SessionPaused
+	updates["pausedAt"] = updates["lastUpdated"]
+
+	return database.UpdateSession(sessionID, updates)
+}
+
+// ResumeSession reactivates a paused session and restarts its expiry window.
+func ResumeSession(sessionID primitive.ObjectID) error {
+	session, err := database.GetSessionData(sessionID)
+	if err != nil {
+		return err
+	}
+	if sessionStatus(session) != models.SessionPaused {
+		return ErrSessionState
+	}
+
+	updates := TouchSession(session)
+	updates["status"] = models.SessionActive
+	updates["pausedAt"] = nil
+
+	return database.UpdateSession(sessionID, updates)
+}
+
+// AbandonSession moves the session into the archive so the user can start a
+// new one without losing what was logged.
+func AbandonSession(sessionID primitive.ObjectID) error {
+	session, err := database.GetSessionData(sessionID)
+	if err != nil {
+		return err
+	}
+
+	return archiveSession(session, models.SessionAbandoned)
+}
+
+// UpdateSessionNotes sets whichever of notes, tags and metadata are present in
+// the DTO on the session.
+func UpdateSessionNotes(sessionID primitive.ObjectID, dto models.SessionNotesDTO) error {
+	session, err := database.GetSessionData(sessionID)
+	if err != nil {
+		return err
+	}
+	if err := ValidateMetadata(dto.Metadata); err != nil {
+		return err
+	}
+
+	updates := TouchSession(session)
+	if dto.Notes != nil {
+		updates["notes"] = strings.TrimSpace(*dto.Notes)
+	}
+	if dto.Tags != nil {
+		updates["tags"] = NormalizeTags(dto.Tags)
+	}
+	if dto.Metadata != nil {
+		MetadataToKg(dto.Metadata, UserWeightUnit(session.UserID))
+		updates["metadata"] = dto.Metadata
+	}
+
+	return database.UpdateSession(sessionID, updates)
+}
+
+// ExpireSessions finishes or archives every session past its expiry. With the
+// "finish" action, the exercises the user already moved past become a partial
+// workout and feed exercise history; everything else is archived. Each session
+// is claimed before anything is written, so overlapping sweeps or a user
+// resuming it at the same time never save it twice.
+func ExpireSessions() (finished, archived int, err error) {
+	now := time.Now()
+	legacyCutoff := now.Add(-time.Duration(config.AppConfig.Sessions.ExpiryHours) * time.Hour)
+
+	sessions, err := database.GetExpiredSessions(now, legacyCutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, listed := range sessions {
+		session, err := database.ClaimExpiredSession(listed.ID, now, legacyCutoff)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to claim expired session %s: %v", listed.ID.Hex(), err)
+			continue
+		}
+
+		if config.AppConfig.Sessions.ExpiryAction == config.SessionExpiryFinish && len(completedExercises(session)) > 0 {
+			err := finishExpiredSession(session)
+			if err == nil {
+				finished++
+				continue
+			}
+			// The session is no longer in sessions; keep it in the archive
+			log.Printf("Failed to finish expired session %s, archiving it: %v", session.ID.Hex(), err)
+		}
+
+		session.Status = models.SessionExpired
+		session.ArchivedAt = primitive.NewDateTimeFromTime(time.Now())
+		if _, err := database.CreateSessionArchive(session); err != nil {
+			log.Printf("Failed to archive expired session %s: %v", session.ID.Hex(), err)
+			continue
+		}
+		archived++
+	}
+
+	return finished, archived, nil
+}
+
+// RunSessionExpiry sweeps expired sessions on the configured interval. It is
+// meant to be started once in its own goroutine.
+func RunSessionExpiry() {
+	interval := time.Duration(config.AppConfig.Sessions.SweepMinutes) * time.Minute
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		finished, archived, err := ExpireSessions()
+		if err != nil {
+			log.Printf("Session expiry sweep failed: %v", err)
+			continue
+		}
+		if finished > 0 || archived > 0 {
+			log.Printf("Session expiry sweep: %d finished, %d archived", finished, archived)
+		}
+	}
+}
+
+// finishExpiredSession saves a claimed session as a partial workout. Once the
+// workout is saved, a failure to update history is only logged so that the
+// session isn't archived as well.
+func finishExpiredSession(session models.WorkoutSession) error {
+	workout := WorkoutFromSession(session)
+	workout.WorkoutDate = session.LastUpdate
+	workout.Exercises = completedExercises(session)
+	workout.Partial = true
+
+	workoutID, err := database.CreateWorkout(workout)
+	if err != nil {
+		return err
+	}
+
+	if err := UpdateExerciseHistory(session.UserID.Hex(), workoutID.Hex()); err != nil {
+		log.Printf("Failed to update history for workout %s: %v", workoutID.Hex(), err)
+	}
+	return nil
+}
+
+func archiveSession(session models.WorkoutSession, status string) error {
+	sessionID := session.ID
+	session.Status = status
+	session.ArchivedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	if _, err := database.CreateSessionArchive(session); err != nil {
+		return err
+	}
+
+	return database.DeleteSession(sessionID)
+}
+
+// completedExercises returns the exercises before the session's current
+// exercise index, i.e. the ones the user has finished.
+func completedExercises(session models.WorkoutSession) []models.WorkoutExercise {
+	done := session.ExerciseIndex
+	if done > len(session.Exercises) {
+		done = len(session.Exercises)
+	}
+	return session.Exercises[:done]
+}
+
+// sessionStatus treats sessions created before statuses existed as active.
+func sessionStatus(session models.WorkoutSession) string {
+	if session.Status == "" {
+		return models.SessionActive
+	}
+	return session.Status
+}
@@ -0,0 +1,451 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+	"fitness-tracker/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrUnknownSex   = errors.New("gender must be male or female to compute scores")
+	ErrNoBodyweight = errors.New("no bodyweight recorded")
+)
+
+// Sexes the scoring formulas and standards are defined for.
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+)
+
+// How a lift's max is taken from history: the best estimated one-rep max of
+// any set, or the heaviest single actually lifted.
+const (
+	BasisE1RM   = "e1rm"
+	BasisActual = "actual"
+)
+
+// The powerlifts, in competition order.
+const (
+	LiftSquat    = "squat"
+	LiftBench    = "bench"
+	LiftDeadlift = "deadlift"
+)
+
+// Strength levels from lowest to highest.
+var StrengthLevels = []string{"beginner", "novice", "intermediate", "advanced", "elite"}
+
+// maxE1RMReps is the highest rep count a one-rep max is estimated from; the
+// Epley formula gets unreliable beyond it.
+const maxE1RMReps = 12
+
+// strengthHistoryWindow is how far back each history point looks for a lift.
+const strengthHistoryWindow = 90 * 24 * time.Hour
+
+// powerlifts maps each lift to its catalog exercise and the variations that
+// count as the competition lift. Sets done with other equipment or
+// variations are ignored.
+var powerlifts = []struct {
+	lift       string
+	slug       string
+	variations []string
+}{
+	{LiftSquat, "squats", []string{"Back"}},
+	{LiftBench, "bench-press", []string{"Flat"}},
+	{LiftDeadlift, "deadlifts", []string{"Conventional", "Sumo"}},
+}
+
+// strengthStandards are the bodyweight multiples at which a lift reaches
+// novice, intermediate, advanced and elite; anything lower is beginner.
+var strengthStandards = map[string]map[string][4]float64{
+	SexMale: {
+		LiftSquat:    {1.25, 1.5, 2.25, 2.75},
+		LiftBench:    {0.75, 1.25, 1.75, 2.0},
+		LiftDeadlift: {1.5, 2.0, 2.5, 3.0},
+	},
+	SexFemale: {
+		LiftSquat:    {0.75, 1.25, 1.75, 2.25},
+		LiftBench:    {0.5, 0.75, 1.0, 1.5},
+		LiftDeadlift: {1.0, 1.25, 1.75, 2.5},
+	},
+}
+
+// LiftStrength is the user's max on one powerlift and where it stands
+// relative to their bodyweight. Weight is the max in kilograms per Basis;
+// SetWeight and Reps are the set it came from.
+type LiftStrength struct {
+	Lift            string             `json:"lift"`
+	ExerciseID      primitive.ObjectID `json:"exercise_id"`
+	Weight          float64            `json:"weight"`
+	SetWeight       float64            `json:"set_weight"`
+	Reps            int                `json:"reps"`
+	Date            time.Time          `json:"date"`
+	BodyweightRatio float64            `json:"bodyweight_ratio"`
+	Level           string             `json:"level"`
+	NextLevel       string             `json:"next_level,omitempty"`
+	NextLevelWeight float64            `json:"next_level_weight,omitempty"`
+}
+
+// StrengthHistoryPoint is the powerlifting total at the end of a month from
+// each lift's best in the preceding 90 days, scored at the bodyweight of
+// that day.
+type StrengthHistoryPoint struct {
+	Date       time.Time `json:"date"`
+	Bodyweight float64   `json:"bodyweight"`
+	Squat      float64   `json:"squat"`
+	Bench      float64   `json:"bench"`
+	Deadlift   float64   `json:"deadlift"`
+	Total      float64   `json:"total"`
+	Wilks      float64   `json:"wilks"`
+	DOTS       float64   `json:"dots"`
+	IPFGL      float64   `json:"ipf_gl"`
+}
+
+// StrengthReport holds the user's powerlifting scores. Scores are only
+// computed once all three lifts have a max (Complete). AgeAdjustedWilks
+// applies the Foster (juniors) and McCulloch (masters) age coefficients.
+type StrengthReport struct {
+	Sex              string                 `json:"sex"`
+	Age              int                    `json:"age,omitempty"`
+	Bodyweight       float64                `json:"bodyweight"`
+	Basis            string                 `json:"basis"`
+	Lifts            []LiftStrength         `json:"lifts"`
+	Complete         bool                   `json:"complete"`
+	Total            float64                `json:"total"`
+	Wilks            float64                `json:"wilks,omitempty"`
+	DOTS             float64                `json:"dots,omitempty"`
+	IPFGL            float64                `json:"ipf_gl,omitempty"`
+	AgeCoefficient   float64                `json:"age_coefficient,omitempty"`
+	AgeAdjustedWilks float64                `json:"age_adjusted_wilks,omitempty"`
+	History          []StrengthHistoryPoint `json:"history"`
+	Unit             string                 `json:"unit,omitempty"`
+}
+
+// liftMax is a candidate max for a lift from one set.
+type liftMax struct {
+	value, weight float64
+	reps          int
+	date          time.Time
+}
+
+// StrengthReportFor scores the user's squat, bench and deadlift. bodyweight
+// is in kilograms; zero uses the latest logged one. History covers the last
+// months calendar months.
+func StrengthReportFor(userID primitive.ObjectID, basis string, bodyweight float64, months int) (*StrengthReport, error) {
+	user, err := database.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	sex := NormalizeSex(user.Gender)
+	if sex == "" {
+		return nil, ErrUnknownSex
+	}
+
+	bodyweights := LoadBodyweightLog(userID)
+	if bodyweight <= 0 {
+		bodyweight = bodyweights.At(time.Now())
+	}
+	if bodyweight <= 0 {
+		return nil, ErrNoBodyweight
+	}
+
+	slugs := make([]string, len(powerlifts))
+	for i, p := range powerlifts {
+		slugs[i] = p.slug
+	}
+	catalog, err := database.GetExercisesBySlugs(slugs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StrengthReport{
+		Sex:        sex,
+		Bodyweight: bodyweight,
+		Basis:      basis,
+		Lifts:      []LiftStrength{},
+		History:    []StrengthHistoryPoint{},
+	}
+	if age, ok := ageOn(user.DateOfBirth, time.Now()); ok {
+		report.Age = age
+	}
+
+	// Every qualifying set per lift, for the current maxes and the history
+	sets := map[string][]liftMax{}
+	for _, p := range powerlifts {
+		exercise, ok := catalog[p.slug]
+		if !ok {
+			continue
+		}
+		history, err := database.GetExerciseHistoryData(exercise.ID, userID)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		sets[p.lift] = liftSets(history, p.variations, basis)
+
+		best, ok := bestLift(sets[p.lift], time.Time{}, time.Now())
+		if !ok {
+			continue
+		}
+		lift := LiftStrength{
+			Lift:       p.lift,
+			ExerciseID: exercise.ID,
+			Weight:     best.value,
+			SetWeight:  best.weight,
+			Reps:       best.reps,
+			Date:       best.date,
+		}
+		lift.BodyweightRatio = units.Round(best.value/bodyweight, 0.01)
+		lift.Level, lift.NextLevel, lift.NextLevelWeight = strengthLevel(sex, p.lift, best.value, bodyweight)
+		report.Lifts = append(report.Lifts, lift)
+		report.Total += best.value
+	}
+
+	report.Complete = len(report.Lifts) == len(powerlifts)
+	if report.Complete {
+		report.Wilks = units.Round(Wilks(sex, bodyweight, report.Total), 0.01)
+		report.DOTS = units.Round(DOTS(sex, bodyweight, report.Total), 0.01)
+		report.IPFGL = units.Round(IPFGL(sex, bodyweight, report.Total), 0.01)
+		if report.Age > 0 {
+			report.AgeCoefficient = AgeCoefficient(report.Age)
+			report.AgeAdjustedWilks = units.Round(report.Wilks*report.AgeCoefficient, 0.01)
+		}
+	}
+
+	if months <= 0 {
+		months = 12
+	}
+	now := UserNow(userID)
+	start := monthStart(now).AddDate(0, -months+1, 0)
+	for month := start; !month.After(now); month = month.AddDate(0, 1, 0) {
+		end := month.AddDate(0, 1, 0)
+		if end.After(now) {
+			end = now
+		}
+
+		point := StrengthHistoryPoint{Date: end, Bodyweight: bodyweights.At(end)}
+		complete := point.Bodyweight > 0
+		for _, p := range powerlifts {
+			best, ok := bestLift(sets[p.lift], end.Add(-strengthHistoryWindow), end)
+			if !ok {
+				complete = false
+				break
+			}
+			switch p.lift {
+			case LiftSquat:
+				point.Squat = best.value
+			case LiftBench:
+				point.Bench = best.value
+			case LiftDeadlift:
+				point.Deadlift = best.value
+			}
+		}
+		if !complete {
+			continue
+		}
+
+		point.Total = point.Squat + point.Bench + point.Deadlift
+		point.Wilks = units.Round(Wilks(sex, point.Bodyweight, point.Total), 0.01)
+		point.DOTS = units.Round(DOTS(sex, point.Bodyweight, point.Total), 0.01)
+		point.IPFGL = units.Round(IPFGL(sex, point.Bodyweight, point.Total), 0.01)
+		report.History = append(report.History, point)
+	}
+
+	return report, nil
+}
+
+// NormalizeSex maps the free-form User.Gender to SexMale or SexFemale, or ""
+// when it is neither.
+func NormalizeSex(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "male", "m", "man":
+		return SexMale
+	case "female", "f", "woman":
+		return SexFemale
+	default:
+		return ""
+	}
+}
+
+// EstimateOneRepMax estimates a one-rep max with the Epley formula. Sets of
+// more than maxE1RMReps reps give no estimate.
+func EstimateOneRepMax(weight float64, reps int) float64 {
+	switch {
+	case reps <= 0 || reps > maxE1RMReps || weight <= 0:
+		return 0
+	case reps == 1:
+		return weight
+	default:
+		return weight * (1 + float64(reps)/30)
+	}
+}
+
+// Wilks scores a total with the original Wilks coefficients. Bodyweight is
+// clamped to the range the formula was fitted on.
+func Wilks(sex string, bodyweight, total float64) float64 {
+	var c [6]float64
+	if sex == SexFemale {
+		bodyweight = math.Min(math.Max(bodyweight, 26.51), 154.53)
+		c = [6]float64{594.31747775582, -27.23842536447, 0.82112226871, -0.00930733913, 4.731582e-05, -9.054e-08}
+	} else {
+		bodyweight = math.Min(math.Max(bodyweight, 40), 201.9)
+		c = [6]float64{-216.0475144, 16.2606339, -0.002388645, -0.00113732, 7.01863e-06, -1.291e-08}
+	}
+	return total * 500 / polynomial(c[:], bodyweight)
+}
+
+// DOTS scores a total with the DOTS coefficients. Bodyweight is clamped to
+// the range the formula was fitted on.
+func DOTS(sex string, bodyweight, total float64) float64 {
+	var c [5]float64
+	if sex == SexFemale {
+		bodyweight = math.Min(math.Max(bodyweight, 40), 150)
+		c = [5]float64{-57.96288, 13.6175032, -0.1126655495, 0.0005158568, -0.0000010706}
+	} else {
+		bodyweight = math.Min(math.Max(bodyweight, 40), 210)
+		c = [5]float64{-307.75076, 24.0900756, -0.1918759221, 0.0007391293, -0.000001093}
+	}
+	return total * 500 / polynomial(c[:], bodyweight)
+}
+
+// IPFGL scores a total with the IPF GL formula for classic (raw)
+// powerlifting.
+func IPFGL(sex string, bodyweight, total float64) float64 {
+	a, b, c := 1199.72839, 1025.18162, 0.00921
+	if sex == SexFemale {
+		a, b, c = 610.32796, 1045.59282, 0.03048
+	}
+	return total * 100 / (a - b*math.Exp(-c*bodyweight))
+}
+
+// AgeCoefficient is the multiplier for age-adjusted Wilks: Foster
+// coefficients below 23, McCulloch coefficients from 41, 1 in between.
+func AgeCoefficient(age int) float64 {
+	switch {
+	case age < 14:
+		return juniorCoefficients[0]
+	case age < 23:
+		return juniorCoefficients[age-14]
+	case age <= 40:
+		return 1
+	case age <= 80:
+		return mastersCoefficients[age-41]
+	default:
+		return mastersCoefficients[len(mastersCoefficients)-1]
+	}
+}
+
+// juniorCoefficients are the Foster coefficients for ages 14 to 22.
+var juniorCoefficients = []float64{1.23, 1.18, 1.13, 1.08, 1.06, 1.04, 1.03, 1.02, 1.01}
+
+// mastersCoefficients are the McCulloch coefficients for ages 41 to 80.
+var mastersCoefficients = []float64{
+	1.010, 1.020, 1.031, 1.043, 1.055, 1.068, 1.082, 1.097, 1.113, 1.130,
+	1.147, 1.165, 1.184, 1.204, 1.225, 1.246, 1.268, 1.291, 1.315, 1.340,
+	1.366, 1.393, 1.421, 1.450, 1.480, 1.511, 1.543, 1.576, 1.610, 1.645,
+	1.681, 1.718, 1.756, 1.795, 1.835, 1.876, 1.918, 1.961, 2.005, 2.050,
+}
+
+func polynomial(coefficients []float64, x float64) float64 {
+	sum, power := 0.0, 1.0
+	for _, c := range coefficients {
+		sum += c * power
+		power *= x
+	}
+	return sum
+}
+
+// liftSets turns a lift's history into candidate maxes, keeping barbell sets
+// of the competition variations (or with none recorded).
+func liftSets(history models.ExerciseHistory, variations []string, basis string) []liftMax {
+	var maxes []liftMax
+	for _, day := range history.Sets {
+		if e := normalizeEquipment(day.Equipment); e != "barbell" && e != "none" && e != "" {
+			continue
+		}
+		if v := day.Variation; v != "" && v != "None" && !containsFold(variations, v) {
+			continue
+		}
+		for _, s := range day.WorkoutSets {
+			value := EstimateOneRepMax(s.Weight, s.Reps)
+			if basis == BasisActual && s.Reps != 1 {
+				value = 0
+			}
+			if value > 0 {
+				maxes = append(maxes, liftMax{value: value, weight: s.Weight, reps: s.Reps, date: day.Date.Time()})
+			}
+		}
+	}
+	return maxes
+}
+
+// bestLift returns the highest max dated in (from, to].
+func bestLift(maxes []liftMax, from, to time.Time) (liftMax, bool) {
+	var best liftMax
+	found := false
+	for _, m := range maxes {
+		if !m.date.After(from) || m.date.After(to) {
+			continue
+		}
+		if !found || m.value > best.value {
+			best = m
+			found = true
+		}
+	}
+	return best, found
+}
+
+// strengthLevel classifies a max against the standards and returns the next
+// level with the weight needed to reach it.
+func strengthLevel(sex, lift string, max, bodyweight float64) (level, next string, nextWeight float64) {
+	thresholds := strengthStandards[sex][lift]
+	ratio := max / bodyweight
+
+	reached := 0
+	for _, t := range thresholds {
+		if ratio >= t {
+			reached++
+		}
+	}
+
+	level = StrengthLevels[reached]
+	if reached < len(thresholds) {
+		next = StrengthLevels[reached+1]
+		nextWeight = thresholds[reached] * bodyweight
+	}
+	return
+}
+
+// ageOn returns the age on date for a date of birth, false if it can't be
+// parsed.
+func ageOn(dateOfBirth string, date time.Time) (int, bool) {
+	if dateOfBirth == "" {
+		return 0, false
+	}
+	born, err := utils.ParseDateParam(dateOfBirth, false)
+	if err != nil || born.After(date) {
+		return 0, false
+	}
+
+	age := date.Year() - born.Year()
+	if date.Month() < born.Month() || (date.Month() == born.Month() && date.Day() < born.Day()) {
+		age--
+	}
+	return age, true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScores(t *testing.T) {
+	tests := []struct {
+		name       string
+		score      func(sex string, bodyweight, total float64) float64
+		sex        string
+		bodyweight float64
+		total      float64
+		want       float64
+	}{
+		{"wilks male", Wilks, SexMale, 100, 700, 426.01},
+		{"wilks female", Wilks, SexFemale, 60, 350, 390.21},
+		{"wilks male at the lower clamp", Wilks, SexMale, 30, 300, 400.63},
+		{"wilks male above the upper clamp", Wilks, SexMale, 250, 900, 478.35},
+		{"dots male", DOTS, SexMale, 100, 700, 430.86},
+		{"dots female", DOTS, SexFemale, 60, 350, 387.99},
+		{"dots male above the upper clamp", DOTS, SexMale, 300, 900, 446.06},
+		{"ipf gl male", IPFGL, SexMale, 100, 700, 88.43},
+		{"ipf gl female", IPFGL, SexFemale, 60, 350, 79.11},
+		{"no total", Wilks, SexMale, 80, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.score(tt.sex, tt.bodyweight, tt.total); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("score(%q, %g, %g) = %.3f, want %.2f", tt.sex, tt.bodyweight, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateOneRepMax(t *testing.T) {
+	tests := []struct {
+		weight float64
+		reps   int
+		want   float64
+	}{
+		{100, 1, 100},
+		{100, 5, 116.667},
+		{60, 10, 80},
+		{100, 12, 140},
+		{100, 13, 0},
+		{100, 0, 0},
+		{0, 5, 0},
+	}
+	for _, tt := range tests {
+		if got := EstimateOneRepMax(tt.weight, tt.reps); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("EstimateOneRepMax(%g, %d) = %.3f, want %.3f", tt.weight, tt.reps, got, tt.want)
+		}
+	}
+}
+
+func TestAgeCoefficient(t *testing.T) {
+	tests := []struct {
+		age  int
+		want float64
+	}{
+		{10, 1.23},
+		{14, 1.23},
+		{22, 1.01},
+		{23, 1},
+		{40, 1},
+		{41, 1.010},
+		{60, 1.340},
+		{80, 2.050},
+		{95, 2.050},
+	}
+	for _, tt := range tests {
+		if got := AgeCoefficient(tt.age); got != tt.want {
+			t.Errorf("AgeCoefficient(%d) = %g, want %g", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeSex(t *testing.T) {
+	tests := []struct {
+		gender string
+		want   string
+	}{
+		{"Male", SexMale},
+		{" m ", SexMale},
+		{"woman", SexFemale},
+		{"F", SexFemale},
+		{"", ""},
+		{"other", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeSex(tt.gender); got != tt.want {
+			t.Errorf("NormalizeSex(%q) = %q, want %q", tt.gender, got, tt.want)
+		}
+	}
+}
+
+func TestStrengthLevel(t *testing.T) {
+	tests := []struct {
+		sex, lift       string
+		max, bodyweight float64
+		level, next     string
+		nextWeight      float64
+	}{
+		{SexMale, LiftSquat, 100, 100, "beginner", "novice", 125},
+		{SexMale, LiftSquat, 150, 100, "intermediate", "advanced", 225},
+		{SexMale, LiftBench, 200, 100, "elite", "", 0},
+		{SexFemale, LiftDeadlift, 75, 60, "intermediate", "advanced", 105},
+	}
+	for _, tt := range tests {
+		level, next, nextWeight := strengthLevel(tt.sex, tt.lift, tt.max, tt.bodyweight)
+		if level != tt.level || next != tt.next || math.Abs(nextWeight-tt.nextWeight) > 1e-9 {
+			t.Errorf("strengthLevel(%q, %q, %g, %g) = %q, %q, %g, want %q, %q, %g",
+				tt.sex, tt.lift, tt.max, tt.bodyweight, level, next, nextWeight, tt.level, tt.next, tt.nextWeight)
+		}
+	}
+}
+
+func TestAgeOn(t *testing.T) {
+	date := time.Date(2024, time.June, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		dateOfBirth string
+		age         int
+		ok          bool
+	}{
+		{"1990-06-15", 34, true},
+		{"1990-06-16", 33, true},
+		{"1990-07-01", 33, true},
+		{"2025-01-01", 0, false},
+		{"", 0, false},
+		{"not a date", 0, false},
+	}
+	for _, tt := range tests {
+		age, ok := ageOn(tt.dateOfBirth, date)
+		if age != tt.age || ok != tt.ok {
+			t.Errorf("ageOn(%q) = %d, %v, want %d, %v", tt.dateOfBirth, age, ok, tt.age, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrExerciseIndex = errors.New("exercise index out of range")
+
+// Substitute is a candidate replacement for a session exercise.
+type Substitute struct {
+	Exercise   models.Exercise `json:"exercise"`
+	Score      float64         `json:"score"`
+	Reasons    []string        `json:"reasons"`
+	HasHistory bool            `json:"has_history"`
+}
+
+// SuggestSubstitutes ranks catalog exercises that could replace the exercise
+// at index in the session. Candidates the user can't perform with
+// userEquipment are dropped; when it is empty the equipment list of the user's
+// inventory is used, if they have one.
+func SuggestSubstitutes(sessionID primitive.ObjectID, index int, userEquipment []string, limit int) ([]Substitute, error) {
+	session, err := database.GetSessionData(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(session.Exercises) {
+		return nil, ErrExerciseIndex
+	}
+
+	current, err := database.GetExerciseData(session.Exercises[index].ExerciseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userEquipment) == 0 {
+		if inventory, ok := UserInventory(session.UserID); ok {
+			userEquipment = inventory.Equipment
+		}
+	}
+
+	historyIDs, err := database.GetUserHistoryExerciseIDs(session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	hasHistory := make(map[primitive.ObjectID]bool, len(historyIDs))
+	for _, id := range historyIDs {
+		hasHistory[id] = true
+	}
+
+	inSession := make(map[primitive.ObjectID]bool, len(session.Exercises))
+	for _, ex := range session.Exercises {
+		inSession[ex.ExerciseID] = true
+	}
+
+	candidates, err := database.SearchExercises(database.ExerciseFilter{OwnerID: session.UserID})
+	if err != nil {
+		return nil, err
+	}
+
+	var substitutes []Substitute
+	for _, candidate := range candidates {
+		if inSession[candidate.ID] || isStaticExercise(candidate.ID) {
+			continue
+		}
+		if len(userEquipment) > 0 && !equipmentAvailable(candidate.Equipment, userEquipment) {
+			continue
+		}
+
+		sub := scoreSubstitute(current, candidate)
+		if sub.Score == 0 {
+			continue
+		}
+		if hasHistory[candidate.ID] {
+			sub.HasHistory = true
+			sub.Score += 1
+			sub.Reasons = append(sub.Reasons, "previously logged")
+		}
+		substitutes = append(substitutes, sub)
+	}
+
+	sort.SliceStable(substitutes, func(i, j int) bool {
+		if substitutes[i].Score != substitutes[j].Score {
+			return substitutes[i].Score > substitutes[j].Score
+		}
+		return substitutes[i].Exercise.Name < substitutes[j].Exercise.Name
+	})
+
+	if limit > 0 && len(substitutes) > limit {
+		substitutes = substitutes[:limit]
+	}
+
+	return substitutes, nil
+}
+
+// SubstituteExercise swaps the exercise at index in the session for the given
+// catalog exercise or one of the user's own. Prescribed sets come from the
+// substitute's own history (falling back to the replaced exercise's reps with
+// no weight), keeping the original set count.
+func SubstituteExercise(sessionID primitive.ObjectID, index int, substituteID primitive.ObjectID) (models.WorkoutExercise, error) {
+	session, err := database.GetSessionData(sessionID)
+	if err != nil {
+		return models.WorkoutExercise{}, err
+	}
+	if sessionStatus(session) != models.SessionActive {
+		return models.WorkoutExercise{}, ErrSessionState
+	}
+	if index < 0 || index >= len(session.Exercises) {
+		return models.WorkoutExercise{}, ErrExerciseIndex
+	}
+
+	// Other users' custom exercises and archived ones are treated as missing,
+	// as they are when routines and sessions are updated
+	substitute, err := database.GetExerciseData(substituteID)
+	if err != nil {
+		return models.WorkoutExercise{}, err
+	}
+	if substitute.Archived || (!substitute.OwnerID.IsZero() && substitute.OwnerID != session.UserID) {
+		return models.WorkoutExercise{}, mongo.ErrNoDocuments
+	}
+
+	replaced := session.Exercises[index]
+	targetSets := len(replaced.Sets)
+	targetReps := 0
+	if targetSets > 0 {
+		targetReps = replaced.Sets[0].Reps
+	}
+
+	exercise := models.WorkoutExercise{
+		ExerciseID: substitute.ID,
+		Equipment:  firstOrNone(substitute.Equipment),
+		Variation:  firstOrNone(substitute.Variations),
+		Name:       substitute.Name,
+		Sets:       []models.WorkoutSet{},
+	}
+
+	i := 0
+	hist, err := database.GetExerciseHistoryData(substitute.ID, session.UserID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return models.WorkoutExercise{}, err
+	}
+	if len(hist.Sets) > 0 {
+		lastSet := hist.Sets[len(hist.Sets)-1]
+		exercise.Equipment = lastSet.Equipment
+		exercise.Variation = lastSet.Variation
+		for ; i < targetSets && i < len(lastSet.WorkoutSets); i++ {
+			exercise.Sets = append(exercise.Sets, models.WorkoutSet{
+				Reps:   lastSet.WorkoutSets[i].Reps,
+				Weight: lastSet.WorkoutSets[i].Weight,
+			})
+		}
+	}
+	for ; i < targetSets; i++ {
+		exercise.Sets = append(exercise.Sets, models.WorkoutSet{
+			Reps:   targetReps,
+			Weight: 0.0,
+		})
+	}
+
+	if inventory, ok := UserInventory(session.UserID); ok {
+		fitExercise(&exercise, *inventory, substitute.Equipment)
+	}
+
+	session.Exercises[index] = exercise
+
+	updates := TouchSession(session)
+	updates["exercises"] = session.Exercises
+
+	if err := database.UpdateSession(sessionID, updates); err != nil {
+		return models.WorkoutExercise{}, err
+	}
+
+	return exercise, nil
+}
+
+// scoreSubstitute rates how well candidate stands in for current, weighting
+// movement pattern and primary muscles above category and equipment. A zero
+// score means the candidate is not a sensible substitute.
+func scoreSubstitute(current, candidate models.Exercise) Substitute {
+	sub := Substitute{Exercise: candidate, Reasons: []string{}}
+
+	if current.Category != "" && strings.EqualFold(current.Category, candidate.Category) {
+		sub.Score += 1
+		sub.Reasons = append(sub.Reasons, "same category")
+	}
+
+	if current.MovementPattern != "" && current.MovementPattern == candidate.MovementPattern {
+		sub.Score += 3
+		sub.Reasons = append(sub.Reasons, "same movement pattern: "+current.MovementPattern)
+	}
+
+	if shared := sharedValues(current.PrimaryMuscles, candidate.PrimaryMuscles); len(shared) > 0 {
+		sub.Score += 2 * float64(len(shared))
+		sub.Reasons = append(sub.Reasons, "same primary muscles: "+strings.Join(shared, ", "))
+	} else if shared := sharedValues(current.PrimaryMuscles, candidate.SecondaryMuscles); len(shared) > 0 {
+		sub.Score += 0.5 * float64(len(shared))
+		sub.Reasons = append(sub.Reasons, "works "+strings.Join(shared, ", ")+" secondarily")
+	}
+
+	if current.Mechanic != "" && current.Mechanic == candidate.Mechanic {
+		sub.Score += 0.5
+	}
+
+	if shared := sharedEquipment(current.Equipment, candidate.Equipment); len(shared) > 0 {
+		sub.Score += float64(len(shared)) * 0.5
+		sub.Reasons = append(sub.Reasons, "shared equipment: "+strings.Join(shared, ", "))
+	}
+
+	return sub
+}
+
+// equipmentAvailable reports whether an exercise can be done with the owned
+// equipment. Bodyweight work, listed as "None" or "Bodyweight" or with no
+// equipment at all, needs nothing and is always available.
+func equipmentAvailable(equipment, owned []string) bool {
+	if len(equipment) == 0 {
+		return true
+	}
+	for _, e := range equipment {
+		if key := normalizeEquipment(e); key == "none" || key == "bodyweight" {
+			return true
+		}
+	}
+	return len(sharedEquipment(equipment, owned)) > 0
+}
+
+// sharedEquipment returns the entries of a that also appear in b, ignoring
+// case and plural forms ("Dumbbell" matches "Dumbbells").
+func sharedEquipment(a, b []string) []string {
+	set := make(map[string]struct{}, len(b))
+	for _, e := range b {
+		set[normalizeEquipment(e)] = struct{}{}
+	}
+
+	var shared []string
+	for _, e := range a {
+		key := normalizeEquipment(e)
+		if key == "none" {
+			continue
+		}
+		if _, ok := set[key]; ok {
+			shared = append(shared, e)
+		}
+	}
+	return shared
+}
+
+func sharedValues(a, b []string) []string {
+	var shared []string
+	for _, v := range a {
+		if slices.Contains(b, v) {
+			shared = append(shared, v)
+		}
+	}
+	return shared
+}
+
+func normalizeEquipment(e string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(e)), "s")
+}
+
+func isStaticExercise(id primitive.ObjectID) bool {
+	hex := id.Hex()
+	return hex == config.AppConfig.StaticExercises.WarmupID || hex == config.AppConfig.StaticExercises.CooldownID
+}
+
+// StaticExerciseIDs returns the configured warm-up and cool-down exercises,
+// for queries that must leave them out.
+func StaticExerciseIDs() []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	for _, hex := range []string{config.AppConfig.StaticExercises.WarmupID, config.AppConfig.StaticExercises.CooldownID} {
+		if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func firstOrNone(values []string) string {
+	if len(values) == 0 {
+		return "None"
+	}
+	return values[0]
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidTimeZone   = errors.New("invalid time zone")
+	ErrInvalidLocalStart = errors.New("local start time must be RFC 3339 with a UTC offset")
+)
+
+// LoadTimeZone resolves an IANA time zone name. The server's "Local" zone is
+// rejected since it means nothing to the client.
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+	return loc, nil
+}
+
+// UserPrefs are the user's weight unit and time zone, for requests that need
+// both from a single read of the user.
+type UserPrefs struct {
+	Unit     string
+	Location *time.Location
+}
+
+// LoadUserPrefs reads the user's unit and time zone, kilograms and UTC if
+// unknown.
+func LoadUserPrefs(userID primitive.ObjectID) UserPrefs {
+	prefs := UserPrefs{Unit: units.Kg, Location: time.UTC}
+	user, err := database.GetUserByID(userID)
+	if err != nil {
+		return prefs
+	}
+	prefs.Unit = units.Normalize(user.UnitPreference)
+	if loc, err := LoadTimeZone(user.TimeZone); err == nil {
+		prefs.Location = loc
+	}
+	return prefs
+}
+
+// Now is the current time in the user's time zone.
+func (p UserPrefs) Now() time.Time {
+	return time.Now().In(p.Location)
+}
+
+// UserLocation returns the user's time zone, UTC if unknown.
+func UserLocation(userID primitive.ObjectID) *time.Location {
+	return LoadUserPrefs(userID).Location
+}
+
+// UserNow is the current time in the user's time zone.
+func UserNow(userID primitive.ObjectID) time.Time {
+	return LoadUserPrefs(userID).Now()
+}
+
+// SetLocalStart records when the workout started on the lifter's clock. A
+// start time sent by the client must carry the offset of that clock and also
+// serves as StartedAt when the session had none. Without one, the session's
+// start is read in the user's time zone.
+func SetLocalStart(workout *models.FullWorkout, clientStart string) error {
+	if clientStart != "" {
+		t, err := time.Parse(time.RFC3339, clientStart)
+		if err != nil {
+			return ErrInvalidLocalStart
+		}
+		workout.LocalStart = t.Format(time.RFC3339)
+		if workout.StartedAt == 0 {
+			workout.StartedAt = primitive.NewDateTimeFromTime(t)
+		}
+		return nil
+	}
+
+	start := workout.StartedAt
+	if start == 0 {
+		start = workout.WorkoutDate
+	}
+	workout.LocalStart = start.Time().In(UserLocation(workout.UserID)).Format(time.RFC3339)
+	return nil
+}
+
+// WorkoutDay is midnight in loc of the day the workout counts for: the date
+// on the lifter's clock when it started, or else the date it was saved in
+// loc. A session started at 23:30 stays on that day when it ends after
+// midnight.
+func WorkoutDay(workout models.FullWorkout, loc *time.Location) time.Time {
+	if workout.LocalStart != "" {
+		if t, err := time.Parse(time.RFC3339, workout.LocalStart); err == nil {
+			y, m, d := t.Date()
+			return time.Date(y, m, d, 0, 0, 0, 0, loc)
+		}
+	}
+	return dayStart(workout.WorkoutDate.Time().In(loc))
+}
+
+// WorkoutStart is when the workout started on the lifter's clock, taken as
+// that wall-clock time in loc so that it falls on WorkoutDay. Without a
+// recorded local start it is when the workout started, or else was saved.
+func WorkoutStart(workout models.FullWorkout, loc *time.Location) time.Time {
+	if workout.LocalStart != "" {
+		if t, err := time.Parse(time.RFC3339, workout.LocalStart); err == nil {
+			y, m, d := t.Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, loc)
+		}
+	}
+	if workout.StartedAt != 0 {
+		return workout.StartedAt.Time().In(loc)
+	}
+	return workout.WorkoutDate.Time().In(loc)
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWorkoutDayAndStart(t *testing.T) {
+	newYork := time.FixedZone("EST", -5*60*60)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	at := func(s string) primitive.DateTime {
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return primitive.NewDateTimeFromTime(parsed)
+	}
+
+	tests := []struct {
+		name    string
+		workout models.FullWorkout
+		loc     *time.Location
+		day     time.Time
+		start   time.Time
+	}{
+		{
+			name: "late session ending after midnight",
+			workout: models.FullWorkout{
+				LocalStart:  "2024-03-04T23:30:00-05:00",
+				StartedAt:   at("2024-03-05T04:30:00Z"),
+				WorkoutDate: at("2024-03-05T05:45:00Z"),
+			},
+			loc:   newYork,
+			day:   time.Date(2024, time.March, 4, 0, 0, 0, 0, newYork),
+			start: time.Date(2024, time.March, 4, 23, 30, 0, 0, newYork),
+		},
+		{
+			name: "started while travelling",
+			workout: models.FullWorkout{
+				LocalStart:  "2024-03-05T07:00:00+09:00",
+				StartedAt:   at("2024-03-04T22:00:00Z"),
+				WorkoutDate: at("2024-03-04T23:00:00Z"),
+			},
+			loc:   newYork,
+			day:   time.Date(2024, time.March, 5, 0, 0, 0, 0, newYork),
+			start: time.Date(2024, time.March, 5, 7, 0, 0, 0, newYork),
+		},
+		{
+			name: "no local start",
+			workout: models.FullWorkout{
+				StartedAt:   at("2024-03-04T16:00:00Z"),
+				WorkoutDate: at("2024-03-04T17:00:00Z"),
+			},
+			loc:   tokyo,
+			day:   time.Date(2024, time.March, 5, 0, 0, 0, 0, tokyo),
+			start: time.Date(2024, time.March, 5, 1, 0, 0, 0, tokyo),
+		},
+		{
+			name:    "only a save date",
+			workout: models.FullWorkout{WorkoutDate: at("2024-03-04T17:00:00Z")},
+			loc:     newYork,
+			day:     time.Date(2024, time.March, 4, 0, 0, 0, 0, newYork),
+			start:   time.Date(2024, time.March, 4, 12, 0, 0, 0, newYork),
+		},
+		{
+			name: "unreadable local start",
+			workout: models.FullWorkout{
+				LocalStart:  "yesterday evening",
+				WorkoutDate: at("2024-03-05T03:00:00Z"),
+			},
+			loc:   newYork,
+			day:   time.Date(2024, time.March, 4, 0, 0, 0, 0, newYork),
+			start: time.Date(2024, time.March, 4, 22, 0, 0, 0, newYork),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if day := WorkoutDay(tt.workout, tt.loc); !day.Equal(tt.day) {
+				t.Errorf("WorkoutDay() = %v, want %v", day, tt.day)
+			}
+			if start := WorkoutStart(tt.workout, tt.loc); !start.Equal(tt.start) {
+				t.Errorf("WorkoutStart() = %v, want %v", start, tt.start)
+			}
+		})
+	}
+}
+
+func TestSetLocalStartFromClient(t *testing.T) {
+	tests := []struct {
+		name        string
+		clientStart string
+		startedAt   primitive.DateTime
+		localStart  string
+		err         error
+	}{
+		{"offset kept", "2024-03-04T23:30:00-05:00", 0, "2024-03-04T23:30:00-05:00", nil},
+		{"session start kept", "2024-03-04T23:30:00-05:00", 1, "2024-03-04T23:30:00-05:00", nil},
+		{"no offset", "2024-03-04T23:30:00", 0, "", ErrInvalidLocalStart},
+		{"not a time", "tonight", 0, "", ErrInvalidLocalStart},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workout := models.FullWorkout{StartedAt: tt.startedAt}
+			err := SetLocalStart(&workout, tt.clientStart)
+			if err != tt.err || workout.LocalStart != tt.localStart {
+				t.Fatalf("SetLocalStart() = %v with %q, want %v with %q", err, workout.LocalStart, tt.err, tt.localStart)
+			}
+			if err != nil {
+				return
+			}
+			want := tt.startedAt
+			if want == 0 {
+				want = primitive.DateTime(time.Date(2024, time.March, 5, 4, 30, 0, 0, time.UTC).UnixMilli())
+			}
+			if workout.StartedAt != want {
+				t.Errorf("StartedAt = %v, want %v", workout.StartedAt.Time(), want.Time())
+			}
+		})
+	}
+}
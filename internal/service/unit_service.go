@@ -0,0 +1,245 @@
+package service
+
+import (
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/models"
+	"fitness-tracker/internal/units"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Weights are stored in kilograms. Handlers convert request bodies with the
+// *ToKg helpers and responses with the *ForDisplay helpers, using the unit of
+// the user the data belongs to.
+
+// UserWeightUnit returns the user's preferred weight unit, kg if unknown.
+func UserWeightUnit(userID primitive.ObjectID) string {
+	return LoadUserPrefs(userID).Unit
+}
+
+// DisplayWeight converts a logged lifting weight for display. Kilograms are
+// shown as stored; converted weights are rounded to one decimal, fine enough
+// that a weight sent back unchanged keeps its value.
+func DisplayWeight(kg float64, unit string) float64 {
+	if unit != units.Lb {
+		return kg
+	}
+	return units.Round(units.FromKg(kg, unit), 0.1)
+}
+
+// DisplayLoad converts a prescribed or suggested weight, one the lifter has
+// to load rather than one they lifted, rounded to the configured plate
+// increment of the unit.
+func DisplayLoad(kg float64, unit string) float64 {
+	step := config.AppConfig.Units.IncrementKg
+	if unit == units.Lb {
+		step = config.AppConfig.Units.IncrementLb
+	}
+	return units.Round(units.FromKg(kg, unit), step)
+}
+
+// DisplayAmount converts a bodyweight or an aggregate such as volume, rounded
+// to one decimal.
+func DisplayAmount(kg float64, unit string) float64 {
+	return units.Round(units.FromKg(kg, unit), 0.1)
+}
+
+func SetsToKg(sets []models.WorkoutSet, unit string) {
+	for i := range sets {
+		sets[i].Weight = units.ToKg(sets[i].Weight, unit)
+	}
+}
+
+func SetsForDisplay(sets []models.WorkoutSet, unit string) {
+	for i := range sets {
+		sets[i].Weight = DisplayWeight(sets[i].Weight, unit)
+	}
+}
+
+func ExercisesToKg(exercises []models.WorkoutExercise, unit string) {
+	for i := range exercises {
+		SetsToKg(exercises[i].Sets, unit)
+	}
+}
+
+func ExercisesForDisplay(exercises []models.WorkoutExercise, unit string) {
+	for i := range exercises {
+		SetsForDisplay(exercises[i].Sets, unit)
+	}
+}
+
+func MetadataToKg(metadata *models.WorkoutMetadata, unit string) {
+	if metadata != nil {
+		metadata.Bodyweight = units.ToKg(metadata.Bodyweight, unit)
+	}
+}
+
+func metadataForDisplay(metadata *models.WorkoutMetadata, unit string) {
+	if metadata != nil {
+		metadata.Bodyweight = DisplayAmount(metadata.Bodyweight, unit)
+	}
+}
+
+func SessionForDisplay(session *models.WorkoutSession, unit string) {
+	ExercisesForDisplay(session.Exercises, unit)
+	metadataForDisplay(session.Metadata, unit)
+	session.Unit = unit
+}
+
+func WorkoutForDisplay(workout *models.FullWorkout, unit string) {
+	ExercisesForDisplay(workout.Exercises, unit)
+	metadataForDisplay(workout.Metadata, unit)
+	workout.Unit = unit
+}
+
+func WorkoutPageForDisplay(page *models.WorkoutSummaryPage, unit string) {
+	for i := range page.Workouts {
+		page.Workouts[i].TotalVolume = DisplayAmount(page.Workouts[i].TotalVolume, unit)
+	}
+	page.Unit = unit
+}
+
+// UserForDisplay shows the user's bodyweight in their own preferred unit.
+func UserForDisplay(user *models.User) {
+	user.Weight = DisplayAmount(user.Weight, units.Normalize(user.UnitPreference))
+}
+
+func ComparisonForDisplay(comparison *WorkoutComparison, unit string) {
+	for i := range comparison.Exercises {
+		ex := &comparison.Exercises[i]
+		ex.MaxWeight = DisplayWeight(ex.MaxWeight, unit)
+		ex.TotalVolume = DisplayAmount(ex.TotalVolume, unit)
+		ex.WeightChange = DisplayWeight(ex.WeightChange, unit)
+		ex.VolumeChange = DisplayAmount(ex.VolumeChange, unit)
+		for j := range ex.SetDeltas {
+			ex.SetDeltas[j].WeightChange = DisplayWeight(ex.SetDeltas[j].WeightChange, unit)
+		}
+		for j := range ex.Sessions {
+			s := &ex.Sessions[j]
+			s.MaxWeight = DisplayWeight(s.MaxWeight, unit)
+			s.TotalVolume = DisplayAmount(s.TotalVolume, unit)
+			SetsForDisplay(s.Sets, unit)
+		}
+	}
+	comparison.Unit = unit
+}
+
+func MuscleVolumeForDisplay(report *MuscleVolumeReport, unit string) {
+	for i := range report.Weeks {
+		for j := range report.Weeks[i].Muscles {
+			report.Weeks[i].Muscles[j].Volume = DisplayAmount(report.Weeks[i].Muscles[j].Volume, unit)
+		}
+	}
+	for i := range report.Average {
+		report.Average[i].Volume = DisplayAmount(report.Average[i].Volume, unit)
+	}
+	report.Unit = unit
+}
+
+// MeasurementForDisplay shows bodyweight in the user's unit and circumferences
+// in the matching length unit, both to one decimal.
+func MeasurementForDisplay(measurement *models.BodyMeasurement, unit string) {
+	lengthUnit := units.LengthUnit(unit)
+	measurement.Bodyweight = DisplayAmount(measurement.Bodyweight, unit)
+	for site, cm := range measurement.Circumferences {
+		measurement.Circumferences[site] = units.Round(units.FromCm(cm, lengthUnit), 0.1)
+	}
+	measurement.Unit = unit
+	measurement.LengthUnit = lengthUnit
+}
+
+func BodyTrendForDisplay(trend *BodyTrend, unit string) {
+	for i := range trend.Points {
+		p := &trend.Points[i]
+		p.Bodyweight = DisplayAmount(p.Bodyweight, unit)
+		p.BodyweightAverage = DisplayAmount(p.BodyweightAverage, unit)
+		p.BodyFatAverage = units.Round(p.BodyFatAverage, 0.1)
+	}
+	trend.WeeklyChange = units.Round(units.FromKg(trend.WeeklyChange, unit), 0.01)
+	trend.WeeklyBodyFatChange = units.Round(trend.WeeklyBodyFatChange, 0.01)
+	trend.Unit = unit
+}
+
+func StrengthReportForDisplay(report *StrengthReport, unit string) {
+	report.Bodyweight = DisplayAmount(report.Bodyweight, unit)
+	report.Total = DisplayAmount(report.Total, unit)
+	for i := range report.Lifts {
+		l := &report.Lifts[i]
+		l.Weight = DisplayAmount(l.Weight, unit)
+		l.SetWeight = DisplayWeight(l.SetWeight, unit)
+		l.NextLevelWeight = DisplayLoad(l.NextLevelWeight, unit)
+	}
+	for i := range report.History {
+		p := &report.History[i]
+		p.Bodyweight = DisplayAmount(p.Bodyweight, unit)
+		p.Squat = DisplayAmount(p.Squat, unit)
+		p.Bench = DisplayAmount(p.Bench, unit)
+		p.Deadlift = DisplayAmount(p.Deadlift, unit)
+		p.Total = DisplayAmount(p.Total, unit)
+	}
+	report.Unit = unit
+}
+
+func GoalForDisplay(goal *models.Goal, unit string) {
+	if goal.Type != models.GoalWorkoutsPerWeek {
+		goal.Target = DisplayAmount(goal.Target, unit)
+		goal.Start = DisplayAmount(goal.Start, unit)
+		goal.Current = DisplayAmount(goal.Current, unit)
+	}
+	goal.Unit = unit
+}
+
+// TrainingLoadForDisplay converts volume loads; sRPE loads have no unit and
+// are only rounded.
+func TrainingLoadForDisplay(report *TrainingLoadReport, unit string) {
+	convert := func(v float64) float64 { return units.Round(v, 0.1) }
+	if report.Method == LoadVolume {
+		convert = func(v float64) float64 { return DisplayAmount(v, unit) }
+		report.Unit = unit
+	}
+	for i := range report.Days {
+		d := &report.Days[i]
+		d.Load = convert(d.Load)
+		d.Acute = convert(d.Acute)
+		d.Chronic = convert(d.Chronic)
+		d.Strain = convert(d.Strain)
+	}
+}
+
+// PlateauReportForDisplay converts e1RM values and deload weights; rep counts
+// are left as they are.
+func PlateauReportForDisplay(report *PlateauReport, unit string) {
+	for i := range report.Exercises {
+		p := &report.Exercises[i]
+		if p.Metric == MetricE1RM {
+			p.Current = DisplayAmount(p.Current, unit)
+			p.Previous = DisplayAmount(p.Previous, unit)
+		} else {
+			p.Current = units.Round(p.Current, 0.1)
+			p.Previous = units.Round(p.Previous, 0.1)
+		}
+		for j := range p.Suggestions {
+			p.Suggestions[j].Weight = DisplayLoad(p.Suggestions[j].Weight, unit)
+		}
+	}
+	report.Unit = unit
+}
+
+func SummaryReportForDisplay(report *SummaryReport, unit string) {
+	report.Totals.Volume = DisplayAmount(report.Totals.Volume, unit)
+	report.Previous.Volume = DisplayAmount(report.Previous.Volume, unit)
+	report.Change.Volume = DisplayAmount(report.Change.Volume, unit)
+	for i := range report.TopLifts {
+		l := &report.TopLifts[i]
+		l.Volume = DisplayAmount(l.Volume, unit)
+		l.Weight = DisplayWeight(l.Weight, unit)
+		l.E1RM = DisplayAmount(l.E1RM, unit)
+	}
+	for i := range report.PRs {
+		p := &report.PRs[i]
+		p.Weight = DisplayWeight(p.Weight, unit)
+		p.E1RM = DisplayAmount(p.E1RM, unit)
+		p.Previous = DisplayAmount(p.Previous, unit)
+	}
+	report.Unit = unit
+}
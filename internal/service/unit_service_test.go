@@ -0,0 +1,55 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/units"
+)
+
+func TestDisplayWeights(t *testing.T) {
+	saved := config.AppConfig.Units
+	config.AppConfig.Units = config.UnitsConfig{IncrementKg: 2.5, IncrementLb: 5}
+	defer func() { config.AppConfig.Units = saved }()
+
+	tests := []struct {
+		name   string
+		kg     float64
+		unit   string
+		weight float64
+		load   float64
+	}{
+		{"kg between plates", 21, units.Kg, 21, 20},
+		{"kg just over a plate", 101, units.Kg, 101, 100},
+		{"kg fractional", 20.25, units.Kg, 20.25, 20},
+		{"lb entered", units.ToKg(225, units.Lb), units.Lb, 225, 225},
+		{"lb between plates", units.ToKg(137.5, units.Lb), units.Lb, 137.5, 140},
+		{"lb from kg", 100, units.Lb, 220.5, 220},
+		{"small change", 1, units.Kg, 1, 0},
+		{"small change in lb", 1, units.Lb, 2.2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DisplayWeight(tt.kg, tt.unit); math.Abs(got-tt.weight) > 1e-9 {
+				t.Errorf("DisplayWeight(%g, %q) = %g, want %g", tt.kg, tt.unit, got, tt.weight)
+			}
+			if got := DisplayLoad(tt.kg, tt.unit); math.Abs(got-tt.load) > 1e-9 {
+				t.Errorf("DisplayLoad(%g, %q) = %g, want %g", tt.kg, tt.unit, got, tt.load)
+			}
+		})
+	}
+}
+
+func TestDisplayWeightRoundTrip(t *testing.T) {
+	for _, entered := range []float64{2.5, 45, 135, 137.5, 225, 315.5, 405} {
+		kg := units.ToKg(entered, units.Lb)
+		shown := DisplayWeight(kg, units.Lb)
+		if shown != entered {
+			t.Errorf("%g lb is shown as %g", entered, shown)
+		}
+		if again := units.ToKg(shown, units.Lb); math.Abs(again-kg) > 1e-9 {
+			t.Errorf("%g lb sent back is stored as %g kg, want %g", shown, again, kg)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"slices"
+	"time"
+
+	"fitness-tracker/internal/config"
+	"fitness-tracker/internal/database"
+	"fitness-tracker/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Training status of a muscle group relative to its weekly set target.
+const (
+	VolumeUnder  = "under"
+	VolumeWithin = "within"
+	VolumeOver   = "over"
+)
+
+// VolumeTarget is the weekly hard-set range considered productive. Muscles
+// overrides it for individual muscle groups.
+type VolumeTarget struct {
+	MinSets float64                 `json:"min_sets"`
+	MaxSets float64                 `json:"max_sets"`
+	Muscles map[string]VolumeTarget `json:"muscles,omitempty"`
+}
+
+// ForMuscle returns the range that applies to a muscle group.
+func (t VolumeTarget) ForMuscle(muscle string) VolumeTarget {
+	if override, ok := t.Muscles[muscle]; ok {
+		return override
+	}
+	return VolumeTarget{MinSets: t.MinSets, MaxSets: t.MaxSets}
+}
+
+type MuscleVolume struct {
+	Muscle    string  `json:"muscle"`
+	Sets      float64 `json:"sets"`
+	Volume    float64 `json:"volume"`
+	Frequency float64 `json:"frequency"`
+	Status    string  `json:"status"`
+}
+
+type MuscleVolumeWeek struct {
+	WeekStart time.Time      `json:"week_start"`
+	Muscles   []MuscleVolume `json:"muscles"`
+}
+
+// MuscleVolumeReport holds per-week volume and the per-week average over the
+// whole range, both compared against the target.
+type MuscleVolumeReport struct {
+	From            time.Time          `json:"from"`
+	To              time.Time          `json:"to"`
+	Target          VolumeTarget       `json:"target"`
+	SecondaryCredit float64            `json:"secondary_credit"`
+	Unit            string             `json:"unit"`
+	Weeks           []MuscleVolumeWeek `json:"weeks"`
+	Average         []MuscleVolume     `json:"average"`
+	Undertrained    []string           `json:"undertrained"`
+	Overtrained     []string           `json:"overtrained"`
+}
+
+// DefaultVolumeTarget returns the configured weekly set target with its
+// per-muscle overrides.
+func DefaultVolumeTarget() VolumeTarget {
+	cfg := config.AppConfig.Analytics
+	target := VolumeTarget{MinSets: cfg.WeeklySetsMin, MaxSets: cfg.WeeklySetsMax}
+	for muscle, r := range cfg.MuscleSets {
+		if !slices.Contains(models.MuscleGroups, muscle) {
+			continue
+		}
+		if target.Muscles == nil {
+			target.Muscles = map[string]VolumeTarget{}
+		}
+		target.Muscles[muscle] = VolumeTarget{MinSets: r.Min, MaxSets: r.Max}
+	}
+	return target
+}
+
+// WeekStart returns midnight on the Monday of t's week, in t's location.
+func WeekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	y, m, d := t.AddDate(0, 0, -offset).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// WeeklyMuscleVolume counts working sets (reps > 0), volume and training
+// frequency per muscle group for every week in [from, to). Primary muscles
+// get full credit for a set and secondary muscles SecondaryCredit of it;
+// frequency counts workouts that trained the muscle as a primary mover.
+// Bodyweight exercises count the user's bodyweight on the day as load.
+func WeeklyMuscleVolume(userID primitive.ObjectID, from, to time.Time, target VolumeTarget) (*MuscleVolumeReport, error) {
+	from = WeekStart(from)
+	if ws := WeekStart(to); ws.Before(to) {
+		to = ws.AddDate(0, 0, 7)
+	}
+
+	workouts, err := database.GetUserWorkoutsInRange(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := exercisesForWorkouts(workouts)
+	if err != nil {
+		return nil, err
+	}
+
+	credit := config.AppConfig.Analytics.SecondaryCredit
+	bodyweights := LoadBodyweightLog(userID)
+
+	type totals struct {
+		sets, volume, frequency float64
+	}
+	var weekStarts []time.Time
+	weeks := map[time.Time]map[string]*totals{}
+	for ws := from; ws.Before(to); ws = ws.AddDate(0, 0, 7) {
+		weekStarts = append(weekStarts, ws)
+		weeks[ws] = map[string]*totals{}
+		for _, m := range models.MuscleGroups {
+			weeks[ws][m] = &totals{}
+		}
+	}
+
+	for _, workout := range workouts {
+		week, ok := weeks[WeekStart(WorkoutDay(workout, from.Location()))]
+		if !ok {
+			continue
+		}
+
+		bodyweight := WorkoutBodyweight(workout, bodyweights)
+		trained := map[string]bool{}
+		for _, exercise := range workout.Exercises {
+			info, ok := catalog[exercise.ExerciseID]
+			if !ok || isStaticExercise(exercise.ExerciseID) {
+				continue
+			}
+
+			load := 0.0
+			if IsBodyweightExercise(info, exercise.Equipment) {
+				load = bodyweight
+			}
+			sets, volume := workingSets(exercise.Sets, load)
+			if sets == 0 {
+				continue
+			}
+
+			for _, m := range info.PrimaryMuscles {
+				if t, ok := week[m]; ok {
+					t.sets += sets
+					t.volume += volume
+					trained[m] = true
+				}
+			}
+			for _, m := range info.SecondaryMuscles {
+				if t, ok := week[m]; ok {
+					t.sets += sets * credit
+					t.volume += volume * credit
+				}
+			}
+		}
+		for m := range trained {
+			week[m].frequency++
+		}
+	}
+
+	report := &MuscleVolumeReport{
+		From:            from,
+		To:              to,
+		Target:          target,
+		SecondaryCredit: credit,
+		Weeks:           make([]MuscleVolumeWeek, 0, len(weekStarts)),
+		Average:         make([]MuscleVolume, 0, len(models.MuscleGroups)),
+		Undertrained:    []string{},
+		Overtrained:     []string{},
+	}
+
+	sum := map[string]*totals{}
+	for _, m := range models.MuscleGroups {
+		sum[m] = &totals{}
+	}
+
+	for _, ws := range weekStarts {
+		weekly := MuscleVolumeWeek{WeekStart: ws, Muscles: make([]MuscleVolume, 0, len(models.MuscleGroups))}
+		for _, m := range models.MuscleGroups {
+			t := weeks[ws][m]
+			weekly.Muscles = append(weekly.Muscles, MuscleVolume{
+				Muscle:    m,
+				Sets:      t.sets,
+				Volume:    t.volume,
+				Frequency: t.frequency,
+				Status:    volumeStatus(t.sets, target.ForMuscle(m)),
+			})
+			sum[m].sets += t.sets
+			sum[m].volume += t.volume
+			sum[m].frequency += t.frequency
+		}
+		report.Weeks = append(report.Weeks, weekly)
+	}
+
+	n := float64(len(weekStarts))
+	if n == 0 {
+		return report, nil
+	}
+	for _, m := range models.MuscleGroups {
+		avg := MuscleVolume{
+			Muscle:    m,
+			Sets:      sum[m].sets / n,
+			Volume:    sum[m].volume / n,
+			Frequency: sum[m].frequency / n,
+		}
+		avg.Status = volumeStatus(avg.Sets, target.ForMuscle(m))
+		switch avg.Status {
+		case VolumeUnder:
+			report.Undertrained = append(report.Undertrained, m)
+		case VolumeOver:
+			report.Overtrained = append(report.Overtrained, m)
+		}
+		report.Average = append(report.Average, avg)
+	}
+
+	return report, nil
+}
+
+// exercisesForWorkouts loads the catalog entries of every exercise in the
+// workouts with one query.
+func exercisesForWorkouts(workouts []models.FullWorkout) (map[primitive.ObjectID]models.Exercise, error) {
+	seen := map[primitive.ObjectID]bool{}
+	var ids []primitive.ObjectID
+	for _, workout := range workouts {
+		for _, exercise := range workout.Exercises {
+			if !seen[exercise.ExerciseID] {
+				seen[exercise.ExerciseID] = true
+				ids = append(ids, exercise.ExerciseID)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return map[primitive.ObjectID]models.Exercise{}, nil
+	}
+	return database.GetExercisesByIDs(ids)
+}
+
+// workoutVolume totals the volume of a workout's working sets, skipping
+// warm-up and cool-down and counting bodyweight for bodyweight exercises.
+func workoutVolume(workout models.FullWorkout, catalog map[primitive.ObjectID]models.Exercise, bodyweights BodyweightLog) float64 {
+	bodyweight := WorkoutBodyweight(workout, bodyweights)
+	total := 0.0
+	for _, exercise := range workout.Exercises {
+		if isStaticExercise(exercise.ExerciseID) {
+			continue
+		}
+		load := 0.0
+		if IsBodyweightExercise(catalog[exercise.ExerciseID], exercise.Equipment) {
+			load = bodyweight
+		}
+		_, volume := workingSets(exercise.Sets, load)
+		total += volume
+	}
+	return total
+}
+
+// workingSets counts sets with reps and their total volume, adding bodyweight
+// to the weight of every set.
+func workingSets(sets []models.WorkoutSet, bodyweight float64) (count, volume float64) {
+	for _, s := range sets {
+		if s.Reps > 0 {
+			count++
+			volume += float64(s.Reps) * (s.Weight + bodyweight)
+		}
+	}
+	return
+}
+
+func volumeStatus(sets float64, target VolumeTarget) string {
+	switch {
+	case sets < target.MinSets:
+		return VolumeUnder
+	case target.MaxSets > 0 && sets > target.MaxSets:
+		return VolumeOver
+	default:
+		return VolumeWithin
+	}
+}
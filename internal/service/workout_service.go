@@ -1,6 +1,8 @@
 package service
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"fitness-tracker/internal/database"
@@ -10,6 +12,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+var ErrInvalidMetadata = errors.New("invalid workout metadata")
+
 // GenerateWorkoutSession builds a workout session based on the user's routine,
 // previous workout, and exercise history.
 func GenerateWorkoutSession(userID, routineID string) (*models.WorkoutSession, error) {
@@ -35,13 +39,18 @@ func GenerateWorkoutSession(userID, routineID string) (*models.WorkoutSession, e
 	}
 
 	workoutExercises := buildExercisesFromRoutine(fullRoutine, lastWorkout, userObjID)
+	fitToEquipment(workoutExercises, userObjID)
 
+	now := time.Now()
 	session := &models.WorkoutSession{
 		UserID:        userObjID,
 		RoutineID:     routineObjID,
 		Exercises:     workoutExercises,
 		ExerciseIndex: 0,
-		LastUpdate:    primitive.NewDateTimeFromTime(time.Now()),
+		LastUpdate:    primitive.NewDateTimeFromTime(now),
+		Status:        models.SessionActive,
+		StartedAt:     primitive.NewDateTimeFromTime(now),
+		ExpiresAt:     primitive.NewDateTimeFromTime(now.Add(SessionExpiry(userObjID))),
 	}
 
 	return session, nil
@@ -109,3 +118,90 @@ func buildExercisesFromRoutine(routine models.FullRoutine, lastWorkout *models.F
 
 	return workoutExercises
 }
+
+// fitToEquipment adapts prescribed exercises to the user's equipment
+// inventory: equipment the user doesn't own is swapped for an owned option the
+// exercise lists, and weights are rounded to what that equipment can load.
+// Users without an inventory keep the prescription unchanged.
+func fitToEquipment(exercises []models.WorkoutExercise, userID primitive.ObjectID) {
+	inventory, ok := UserInventory(userID)
+	if !ok || len(exercises) == 0 {
+		return
+	}
+
+	ids := make([]primitive.ObjectID, len(exercises))
+	for i, ex := range exercises {
+		ids[i] = ex.ExerciseID
+	}
+	catalog, err := database.GetExercisesByIDs(ids)
+	if err != nil {
+		return
+	}
+
+	for i := range exercises {
+		fitExercise(&exercises[i], *inventory, catalog[exercises[i].ExerciseID].Equipment)
+	}
+}
+
+func fitExercise(ex *models.WorkoutExercise, inventory models.EquipmentInventory, options []string) {
+	if len(options) > 0 {
+		ex.EquipmentOptions = OwnedEquipment(&inventory, options)
+		ex.Equipment = chooseEquipment(ex.Equipment, ex.EquipmentOptions)
+	}
+	for j := range ex.Sets {
+		ex.Sets[j].Weight = RoundToLoadable(inventory, ex.Equipment, ex.Sets[j].Weight)
+	}
+}
+
+// WorkoutFromSession builds the workout that gets saved when a session is
+// finished, carrying over notes, tags and metadata.
+func WorkoutFromSession(session models.WorkoutSession) models.FullWorkout {
+	exercises := make([]models.WorkoutExercise, len(session.Exercises))
+	for i, ex := range session.Exercises {
+		ex.EquipmentOptions = nil
+		exercises[i] = ex
+	}
+
+	return models.FullWorkout{
+		ID:          primitive.NilObjectID,
+		UserID:      session.UserID,
+		RoutineID:   session.RoutineID,
+		WorkoutDate: primitive.NewDateTimeFromTime(time.Now()),
+		Exercises:   exercises,
+		Notes:       session.Notes,
+		Tags:        session.Tags,
+		Metadata:    session.Metadata,
+		StartedAt:   session.StartedAt,
+	}
+}
+
+// NormalizeTags trims, lower-cases and de-duplicates user tags, dropping empty
+// ones while keeping the original order.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]struct{}, len(tags))
+	normalized := []string{}
+	for _, t := range tags {
+		tag := strings.ToLower(strings.TrimSpace(t))
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// ValidateMetadata rejects metadata values outside sensible bounds.
+func ValidateMetadata(metadata *models.WorkoutMetadata) error {
+	if metadata == nil {
+		return nil
+	}
+	if metadata.Bodyweight < 0 || metadata.Difficulty < 0 || metadata.Difficulty > 10 {
+		return ErrInvalidMetadata
+	}
+	metadata.Location = strings.TrimSpace(metadata.Location)
+	return nil
+}
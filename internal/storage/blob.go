@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+
+	"fitness-tracker/internal/config"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps uploaded files such as exercise demo media. Keys are
+// slash-separated paths chosen by the caller.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) (size int64, err error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Blobs is the store configured at startup.
+var Blobs BlobStore
+
+// InitBlobStore sets up the configured blob store backend.
+func InitBlobStore() {
+	cfg := config.AppConfig.Media
+
+	switch cfg.Store {
+	case config.MediaStoreLocal:
+		store, err := NewLocalStore(cfg.LocalRoot)
+		if err != nil {
+			log.Fatalf("Failed to initialise local blob store: %v", err)
+		}
+		Blobs = store
+	default:
+		log.Fatalf("Unknown MEDIA_STORE %q", cfg.Store)
+	}
+}
@@ -0,0 +1,88 @@
+// Package units converts weights between kilograms, the storage unit, and the
+// unit a user prefers to see.
+package units
+
+import (
+	"math"
+	"strings"
+)
+
+// Weight units. Everything is stored in kilograms.
+const (
+	Kg = "kg"
+	Lb = "lb"
+)
+
+// KgPerLb is the exact definition of the international avoirdupois pound.
+const KgPerLb = 0.45359237
+
+// Normalize maps the free-form values stored in User.UnitPreference ("lbs",
+// "imperial", "Metric", ...) to Kg or Lb. Anything unrecognised is Kg.
+func Normalize(preference string) string {
+	switch strings.ToLower(strings.TrimSpace(preference)) {
+	case "lb", "lbs", "pound", "pounds", "imperial":
+		return Lb
+	default:
+		return Kg
+	}
+}
+
+// ToKg converts a weight given in unit to kilograms. No rounding is applied so
+// that converting back returns what the user entered.
+func ToKg(weight float64, unit string) float64 {
+	if unit == Lb {
+		return weight * KgPerLb
+	}
+	return weight
+}
+
+// FromKg converts a stored weight to unit.
+func FromKg(kg float64, unit string) float64 {
+	if unit == Lb {
+		return kg / KgPerLb
+	}
+	return kg
+}
+
+// Round rounds value to the nearest multiple of step; a non-positive step
+// leaves it unchanged.
+func Round(value, step float64) float64 {
+	if step <= 0 {
+		return value
+	}
+	return math.Round(value/step) * step
+}
+
+// Length units for body measurements, stored in centimetres. Users preferring
+// pounds see inches.
+const (
+	Cm = "cm"
+	In = "in"
+)
+
+// CmPerIn is the exact definition of the international inch.
+const CmPerIn = 2.54
+
+// LengthUnit returns the length unit that goes with a weight unit.
+func LengthUnit(weightUnit string) string {
+	if weightUnit == Lb {
+		return In
+	}
+	return Cm
+}
+
+// ToCm converts a length given in unit to centimetres.
+func ToCm(length float64, unit string) float64 {
+	if unit == In {
+		return length * CmPerIn
+	}
+	return length
+}
+
+// FromCm converts a stored length to unit.
+func FromCm(cm float64, unit string) float64 {
+	if unit == In {
+		return cm / CmPerIn
+	}
+	return cm
+}
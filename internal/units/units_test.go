@@ -0,0 +1,91 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		preference string
+		want       string
+	}{
+		{"kg", Kg},
+		{"lb", Lb},
+		{"lbs", Lb},
+		{" Pounds ", Lb},
+		{"Imperial", Lb},
+		{"metric", Kg},
+		{"", Kg},
+		{"stone", Kg},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.preference); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.preference, got, tt.want)
+		}
+	}
+}
+
+func TestWeightConversion(t *testing.T) {
+	tests := []struct {
+		weight float64
+		unit   string
+		kg     float64
+	}{
+		{100, Kg, 100},
+		{0, Lb, 0},
+		{1, Lb, KgPerLb},
+		{225, Lb, 102.05828325},
+		{45, Lb, 20.41165665},
+	}
+	for _, tt := range tests {
+		kg := ToKg(tt.weight, tt.unit)
+		if math.Abs(kg-tt.kg) > 1e-9 {
+			t.Errorf("ToKg(%g, %q) = %g, want %g", tt.weight, tt.unit, kg, tt.kg)
+		}
+		if back := FromKg(kg, tt.unit); math.Abs(back-tt.weight) > 1e-9 {
+			t.Errorf("FromKg(ToKg(%g, %q)) = %g, want the weight back", tt.weight, tt.unit, back)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		value, step float64
+		want        float64
+	}{
+		{101.3, 2.5, 102.5},
+		{101.2, 2.5, 100},
+		{226.8, 5, 225},
+		{227.5, 5, 230},
+		{0.1234, 0.001, 0.123},
+		{42.42, 0, 42.42},
+		{42.42, -1, 42.42},
+	}
+	for _, tt := range tests {
+		if got := Round(tt.value, tt.step); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Round(%g, %g) = %g, want %g", tt.value, tt.step, got, tt.want)
+		}
+	}
+}
+
+func TestLengthConversion(t *testing.T) {
+	tests := []struct {
+		weightUnit string
+		length     float64
+		cm         float64
+	}{
+		{Kg, 40, 40},
+		{Lb, 15, 38.1},
+	}
+	for _, tt := range tests {
+		unit := LengthUnit(tt.weightUnit)
+		cm := ToCm(tt.length, unit)
+		if math.Abs(cm-tt.cm) > 1e-9 {
+			t.Errorf("ToCm(%g, %q) = %g, want %g", tt.length, unit, cm, tt.cm)
+		}
+		if back := FromCm(cm, unit); math.Abs(back-tt.length) > 1e-9 {
+			t.Errorf("FromCm(ToCm(%g, %q)) = %g, want the length back", tt.length, unit, back)
+		}
+	}
+}
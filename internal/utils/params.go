@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ParseDateParam parses a query parameter given either as RFC 3339 or as a
+// plain YYYY-MM-DD date (midnight UTC). When inclusiveEnd is set, a plain date
+// is moved to the following midnight so that it can be used as an exclusive
+// upper bound covering the whole day. An empty value yields the zero time.
+func ParseDateParam(value string, inclusiveEnd bool) (time.Time, error) {
+	return ParseDateParamIn(value, inclusiveEnd, time.UTC)
+}
+
+// ParseDateParamIn is ParseDateParam with plain dates taken as midnight in
+// loc rather than UTC.
+func ParseDateParamIn(value string, inclusiveEnd bool, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if inclusiveEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// ParseLimitParam parses a page size, returning def when the value is empty
+// and capping it at max.
+func ParseLimitParam(value string, def, max int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	if limit > max {
+		limit = max
+	}
+	return limit, nil
+}
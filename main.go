@@ -1,21 +1,72 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"log"
 	"net/http"
+	"os"
 
 	"fitness-tracker/internal/config"
 	"fitness-tracker/internal/database"
 	"fitness-tracker/internal/routes"
+	"fitness-tracker/internal/service"
+	"fitness-tracker/internal/storage"
 )
 
 func main() {
+	syncExercises := flag.Bool("sync-exercises", false, "sync the exercise catalog from the JSON file and exit")
+	migrateUnits := flag.Bool("migrate-units", false, "convert weights of users who log in pounds to kilogram storage and exit")
+	dryRun := flag.Bool("dry-run", false, "with -sync-exercises or -migrate-units, report changes without writing them")
+	flag.Parse()
+
 	log.Println("Loding app config...")
 	config.LoadConfig()
 
 	log.Println("Initialising database connection...")
 	database.InitMongo()
 
+	log.Println("Initialising media store...")
+	storage.InitBlobStore()
+
+	if *syncExercises {
+		path := config.AppConfig.ExercisesJSONPath
+		log.Printf("Syncing exercises from %s (dry run: %v)...", path, *dryRun)
+		report, err := database.SyncExercisesFromJSON(context.Background(), database.MongoDatabase, path, *dryRun)
+		if err != nil {
+			log.Fatalf("Exercise sync failed: %v", err)
+		}
+		printReport(report)
+		return
+	}
+
+	if *migrateUnits {
+		log.Printf("Migrating stored weights to kilograms (dry run: %v)...", *dryRun)
+		report, err := database.MigrateWeightsToKg(context.Background(), database.MongoDatabase, *dryRun)
+		if err != nil {
+			log.Fatalf("Weight migration failed: %v", err)
+		}
+		printReport(report)
+		return
+	}
+
+	// Weights are converted to kilograms at the API boundary, so serving an
+	// unmigrated database would mix units in the same user's data
+	pending, err := database.CountPendingWeightMigration(context.Background(), database.MongoDatabase)
+	if err != nil {
+		log.Fatalf("Failed to check the weight migration: %v", err)
+	}
+	if pending > 0 {
+		log.Printf("Migrating stored weights of %d users to kilograms...", pending)
+		if _, err := database.MigrateWeightsToKg(context.Background(), database.MongoDatabase, false); err != nil {
+			log.Fatalf("Weight migration failed: %v", err)
+		}
+	}
+
+	log.Println("Starting session expiry sweeper...")
+	go service.RunSessionExpiry()
+
 	log.Println("Registering routes and multiplexer...")
 	mux := http.NewServeMux()
 	routes.RegisterRoutes(mux)
@@ -23,3 +74,9 @@ func main() {
 	log.Printf("Server running on :%s", config.AppConfig.Port)
 	log.Fatal(http.ListenAndServe(":"+config.AppConfig.Port, mux))
 }
+
+func printReport(report interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+}